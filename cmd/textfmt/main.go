@@ -2,6 +2,7 @@
 package main
 
 import (
+	"encoding/json"
 	"errors"
 	"flag"
 	"fmt"
@@ -9,18 +10,34 @@ import (
 	"os"
 	"strings"
 
+	"go-reloaded/internal/engine"
 	"go-reloaded/internal/runner"
 )
 
 const version = "0.1.0"
 
+// formatMarkersCommand selects the mode that only canonicalises marker
+// spelling and leaves the prose untouched.
+const formatMarkersCommand = "fmt-markers"
+
 type options struct {
 	showHelp    bool
 	showVersion bool
 	useStdin    bool
 	useStdout   bool
-	inputPath   string
-	outputPath  string
+	strict      bool
+	lenient     bool
+	compounds   bool
+	// formatMarkers is set by the fmt-markers subcommand.
+	formatMarkers bool
+	locale        string
+	protectPath   string
+	macrosPath    string
+	// vars holds the --var name=value flags, varsPath the --vars file.
+	vars       map[string]string
+	varsPath   string
+	inputPath  string
+	outputPath string
 }
 
 func main() {
@@ -66,22 +83,39 @@ func run(args []string, stdin io.Reader, stdout io.Writer, stderr io.Writer) int
 	}
 	defer closeInput()
 
-	output, closeOutput, err := resolveOutput(opts, stdout)
+	res, err := process(opts, input)
 	if err != nil {
+		var strictErr *runner.StrictError
+		if errors.As(err, &strictErr) {
+			for _, d := range strictErr.Diagnostics {
+				if writeErr := writef(stderr, "error: %s\n", d); writeErr != nil {
+					return 1
+				}
+			}
+		}
 		if writeErr := writef(stderr, "error: %v\n", err); writeErr != nil {
 			return 1
 		}
 		return 1
 	}
-	defer closeOutput()
+	result := res.Output
+
+	for _, d := range res.Diagnostics {
+		if err := writef(stderr, "%s: %s\n", d.Severity, d); err != nil {
+			return 1
+		}
+	}
 
-	result, err := runner.Run(input)
+	// The output is only opened once the input has been accepted, so a
+	// rejected document never leaves a partial output file behind.
+	output, closeOutput, err := resolveOutput(opts, stdout)
 	if err != nil {
 		if writeErr := writef(stderr, "error: %v\n", err); writeErr != nil {
 			return 1
 		}
 		return 1
 	}
+	defer closeOutput()
 
 	if _, err := io.WriteString(output, result); err != nil {
 		if writeErr := writef(stderr, "error writing output: %v\n", err); writeErr != nil {
@@ -90,7 +124,7 @@ func run(args []string, stdin io.Reader, stdout io.Writer, stderr io.Writer) int
 		return 1
 	}
 
-	if opts.useStdout && !strings.HasSuffix(result, "\n") {
+	if opts.useStdout && !opts.formatMarkers && !strings.HasSuffix(result, "\n") {
 		if _, err := io.WriteString(output, "\n"); err != nil {
 			if writeErr := writef(stderr, "error writing newline: %v\n", err); writeErr != nil {
 				return 1
@@ -102,8 +136,115 @@ func run(args []string, stdin io.Reader, stdout io.Writer, stderr io.Writer) int
 	return 0
 }
 
+// process runs the pipeline selected by opts over input.
+func process(opts options, input io.Reader) (runner.Result, error) {
+	runOpts := runner.Options{
+		Strict:    opts.strict,
+		Lenient:   opts.lenient,
+		Locale:    opts.locale,
+		Compounds: opts.compounds,
+	}
+	if opts.macrosPath != "" {
+		registry, err := loadMacros(opts.macrosPath)
+		if err != nil {
+			return runner.Result{}, err
+		}
+		runOpts.Registry = registry
+	}
+	if opts.protectPath != "" {
+		protected, err := loadProtectedWords(opts.protectPath)
+		if err != nil {
+			return runner.Result{}, err
+		}
+		runOpts.Protected = protected
+	}
+	vars, err := loadVars(opts)
+	if err != nil {
+		return runner.Result{}, err
+	}
+	runOpts.Vars = vars
+	if opts.formatMarkers {
+		output, err := runner.FormatMarkers(input, runOpts)
+		return runner.Result{Output: output}, err
+	}
+	return runner.RunWithOptions(input, runOpts)
+}
+
+// loadProtectedWords returns the built-in protected words extended with the
+// ones listed in the file at path.
+func loadProtectedWords(path string) (engine.ProtectedWords, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("open protected words: %w", err)
+	}
+	defer func() { _ = file.Close() }()
+
+	protected := engine.DefaultProtectedWords()
+	if err := protected.Load(file); err != nil {
+		return nil, err
+	}
+	return protected, nil
+}
+
+// loadMacros returns the built-in markers extended with the macros defined in
+// the file at path.
+func loadMacros(path string) (*engine.Registry, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("open macros: %w", err)
+	}
+	defer func() { _ = file.Close() }()
+
+	registry := engine.NewRegistry()
+	if err := registry.LoadMacros(file); err != nil {
+		return nil, err
+	}
+	return registry, nil
+}
+
+// loadVars merges the variables from the --vars file with the --var flags,
+// which take precedence.
+func loadVars(opts options) (map[string]string, error) {
+	vars := make(map[string]string)
+	if opts.varsPath != "" {
+		file, err := os.Open(opts.varsPath)
+		if err != nil {
+			return nil, fmt.Errorf("open vars: %w", err)
+		}
+		defer func() { _ = file.Close() }()
+
+		decoder := json.NewDecoder(file)
+		decoder.UseNumber()
+		var raw map[string]any
+		if err := decoder.Decode(&raw); err != nil {
+			return nil, fmt.Errorf("parse vars %s: %w", opts.varsPath, err)
+		}
+		for name, value := range raw {
+			switch v := value.(type) {
+			case string:
+				vars[name] = v
+			case json.Number:
+				vars[name] = v.String()
+			case bool:
+				vars[name] = fmt.Sprint(v)
+			default:
+				return nil, fmt.Errorf("parse vars %s: %q must be a string, number or boolean", opts.varsPath, name)
+			}
+		}
+	}
+	for name, value := range opts.vars {
+		vars[name] = value
+	}
+	return vars, nil
+}
+
 func parseArgs(args []string) (options, error) {
 	var opts options
+	if len(args) > 0 && args[0] == formatMarkersCommand {
+		opts.formatMarkers = true
+		args = args[1:]
+	}
+
 	fs := flag.NewFlagSet("textfmt", flag.ContinueOnError)
 	fs.SetOutput(io.Discard)
 
@@ -113,6 +254,24 @@ func parseArgs(args []string) (options, error) {
 	fs.BoolVar(&opts.showVersion, "version", false, "show version")
 	fs.BoolVar(&opts.useStdin, "stdin", false, "read from stdin")
 	fs.BoolVar(&opts.useStdout, "stdout", false, "write to stdout")
+	fs.BoolVar(&opts.strict, "strict", false, "fail on invalid or unused markers")
+	fs.BoolVar(&opts.lenient, "lenient", false, "accept markers with non-canonical spacing or case")
+	fs.StringVar(&opts.locale, "locale", "", "locale for case transforms, e.g. tr or lt")
+	fs.StringVar(&opts.macrosPath, "macros", "", "file of user-defined markers built from existing ones")
+	fs.StringVar(&opts.protectPath, "protect", "", "file of extra words whose spelling case markers keep")
+	fs.Func("var", "set a template variable, as name=value", func(s string) error {
+		name, value, ok := strings.Cut(s, "=")
+		if !ok || name == "" {
+			return fmt.Errorf("invalid variable %q, want name=value", s)
+		}
+		if opts.vars == nil {
+			opts.vars = make(map[string]string)
+		}
+		opts.vars[name] = value
+		return nil
+	})
+	fs.StringVar(&opts.varsPath, "vars", "", "JSON file of template variables")
+	fs.BoolVar(&opts.compounds, "compounds", false, "count hyphenated and slash-joined compounds as one word")
 
 	if err := fs.Parse(args); err != nil {
 		return options{}, err
@@ -181,12 +340,25 @@ func resolveOutput(opts options, stdout io.Writer) (io.Writer, func(), error) {
 func printUsage(w io.Writer) error {
 	lines := []string{
 		"Usage: textfmt [flags] <input> <output>",
+		"       textfmt fmt-markers [flags] <input> <output>",
+		"",
+		"Commands:",
+		"  fmt-markers      Rewrite marker spelling to canonical form, e.g. (up,3) to (up, 3),",
+		"                   without applying markers or touching the surrounding text",
 		"",
 		"Flags:",
 		"  -h, --help       Show this help message",
 		"  -v, --version    Show version information",
 		"      --stdin      Read input from STDIN instead of a file",
 		"      --stdout     Write output to STDOUT instead of a file",
+		"      --strict     Fail instead of warning when a marker is invalid, unused or only partly applied",
+		"      --lenient    Accept markers such as (UP) or ( cap ,2 ) and note their canonical spelling",
+		"      --locale L   Use the case rules of locale L, e.g. tr for the dotted and dotless i",
+		"      --macros F   Define markers such as heading = title then trailing \":\" from file F",
+		"      --protect F  Also keep the spelling of the words listed in F, one per line",
+		"      --var N=V    Expand (var, N) to V; may be repeated and overrides --vars",
+		"      --vars F     Read template variables from the JSON object in file F",
+		"      --compounds  Count compounds such as state-of-the-art as one word in marker counts",
 	}
 
 	for _, line := range lines {
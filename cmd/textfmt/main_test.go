@@ -3,6 +3,7 @@ package main
 import (
 	"io"
 	"os"
+	"reflect"
 	"strings"
 	"testing"
 )
@@ -72,6 +73,84 @@ func TestParseArgs(t *testing.T) {
 			args:      []string{"--stdout", "input.txt", "output.txt"},
 			expectErr: true,
 		},
+		{
+			name: "strict mode",
+			args: []string{"--strict", "input.txt", "output.txt"},
+			expect: options{
+				strict:     true,
+				inputPath:  "input.txt",
+				outputPath: "output.txt",
+			},
+		},
+		{
+			name: "lenient mode",
+			args: []string{"--lenient", "--stdin", "--stdout"},
+			expect: options{
+				lenient:   true,
+				useStdin:  true,
+				useStdout: true,
+			},
+		},
+		{
+			name: "compounds mode",
+			args: []string{"--compounds", "--stdin", "--stdout"},
+			expect: options{
+				compounds: true,
+				useStdin:  true,
+				useStdout: true,
+			},
+		},
+		{
+			name: "locale",
+			args: []string{"--locale", "tr", "--stdin", "--stdout"},
+			expect: options{
+				locale:    "tr",
+				useStdin:  true,
+				useStdout: true,
+			},
+		},
+		{
+			name: "protected words file",
+			args: []string{"--protect", "words.txt", "--stdin", "--stdout"},
+			expect: options{
+				protectPath: "words.txt",
+				useStdin:    true,
+				useStdout:   true,
+			},
+		},
+		{
+			name: "template variables",
+			args: []string{"--var", "product=Foo", "--var", "tag=a=b", "--vars", "vars.json", "--stdin", "--stdout"},
+			expect: options{
+				vars:      map[string]string{"product": "Foo", "tag": "a=b"},
+				varsPath:  "vars.json",
+				useStdin:  true,
+				useStdout: true,
+			},
+		},
+		{
+			name:      "variable without value",
+			args:      []string{"--var", "product", "--stdin", "--stdout"},
+			expectErr: true,
+		},
+		{
+			name: "macros file",
+			args: []string{"--macros", "macros.txt", "--stdin", "--stdout"},
+			expect: options{
+				macrosPath: "macros.txt",
+				useStdin:   true,
+				useStdout:  true,
+			},
+		},
+		{
+			name: "fmt-markers command",
+			args: []string{"fmt-markers", "in.txt", "out.txt"},
+			expect: options{
+				formatMarkers: true,
+				inputPath:     "in.txt",
+				outputPath:    "out.txt",
+			},
+		},
 		{
 			name: "stdin to file",
 			args: []string{"--stdin", "output.txt"},
@@ -96,7 +175,7 @@ func TestParseArgs(t *testing.T) {
 			if err != nil {
 				t.Fatalf("unexpected error: %v", err)
 			}
-			if got != tc.expect {
+			if !reflect.DeepEqual(got, tc.expect) {
 				t.Fatalf("unexpected options: %#v", got)
 			}
 		})
@@ -152,6 +231,50 @@ func TestRun(t *testing.T) {
 			expectCode: 0,
 			expectOut:  "hello world",
 		},
+		{
+			name:       "marker warnings go to stderr",
+			args:       []string{"--stdin", "--stdout"},
+			stdin:      "Good morning (up, -1)",
+			expectCode: 0,
+			expectOut:  "Good morning",
+			expectErr:  "warning: byte 13: (up, -1): count must be positive, got -1",
+		},
+		{
+			name:       "strict mode rejects unused marker",
+			args:       []string{"--strict", "--stdin", "--stdout"},
+			stdin:      "Good morning (up, -1)",
+			expectCode: 1,
+			expectErr:  "error: byte 13: (up, -1): count must be positive, got -1",
+		},
+		{
+			name:       "lenient markers are noted",
+			args:       []string{"--lenient", "--stdin", "--stdout"},
+			stdin:      "go (UP)",
+			expectCode: 0,
+			expectOut:  "GO",
+			expectErr:  "note: byte 3: (UP): non-canonical spelling, write (up)",
+		},
+		{
+			name:       "compounds count as one word",
+			args:       []string{"--compounds", "--stdin", "--stdout"},
+			stdin:      "a well-known fact (up, 2)",
+			expectCode: 0,
+			expectOut:  "a WELL-KNOWN FACT",
+		},
+		{
+			name:       "locale-specific casing",
+			args:       []string{"--locale", "tr", "--stdin", "--stdout"},
+			stdin:      "izmir (up)",
+			expectCode: 0,
+			expectOut:  "İZMİR",
+		},
+		{
+			name:       "fmt-markers canonicalises without applying",
+			args:       []string{"fmt-markers", "--stdin", "--stdout"},
+			stdin:      "Hello world(up,3) ,  see ( cap )",
+			expectCode: 0,
+			expectOut:  "Hello world(up, 3) ,  see (cap)",
+		},
 		{
 			name:       "missing input",
 			args:       []string{},
@@ -229,6 +352,125 @@ func TestRun(t *testing.T) {
 	}
 }
 
+func TestRunStrictLeavesNoOutputFile(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	input := dir + "/input.txt"
+	output := dir + "/output.txt"
+	if err := os.WriteFile(input, []byte("zz (hex)"), 0644); err != nil {
+		t.Fatalf("failed to create input: %v", err)
+	}
+
+	var stdout, stderr strings.Builder
+	if code := run([]string{"--strict", input, output}, strings.NewReader(""), &stdout, &stderr); code != 1 {
+		t.Fatalf("expected exit code 1, got %d (stderr %q)", code, stderr.String())
+	}
+	if _, err := os.Stat(output); !os.IsNotExist(err) {
+		t.Fatalf("expected no output file, stat returned %v", err)
+	}
+	if !strings.Contains(stderr.String(), "strict mode: 1 marker could not be applied") {
+		t.Fatalf("unexpected stderr: %q", stderr.String())
+	}
+}
+
+func TestRunProtectedWordsFile(t *testing.T) {
+	t.Parallel()
+
+	path := t.TempDir() + "/protected.txt"
+	if err := os.WriteFile(path, []byte("# brands\nAcmeCorp\n"), 0644); err != nil {
+		t.Fatalf("failed to create protected words: %v", err)
+	}
+
+	var stdout, stderr strings.Builder
+	args := []string{"--protect", path, "--stdin", "--stdout"}
+	if code := run(args, strings.NewReader("ACMECORP and NASA (low, 3)"), &stdout, &stderr); code != 0 {
+		t.Fatalf("expected exit code 0, got %d (stderr %q)", code, stderr.String())
+	}
+	if want := "AcmeCorp and NASA\n"; stdout.String() != want {
+		t.Fatalf("unexpected output: want %q, got %q", want, stdout.String())
+	}
+
+	stdout.Reset()
+	stderr.Reset()
+	args = []string{"--protect", t.TempDir() + "/missing.txt", "--stdin", "--stdout"}
+	if code := run(args, strings.NewReader("x"), &stdout, &stderr); code != 1 {
+		t.Fatalf("expected exit code 1 for a missing file, got %d", code)
+	}
+	if !strings.Contains(stderr.String(), "open protected words") {
+		t.Fatalf("unexpected stderr: %q", stderr.String())
+	}
+}
+
+func TestRunVarsFile(t *testing.T) {
+	t.Parallel()
+
+	path := t.TempDir() + "/vars.json"
+	if err := os.WriteFile(path, []byte(`{"product": "Foo", "version": 2, "beta": true}`), 0644); err != nil {
+		t.Fatalf("failed to create vars file: %v", err)
+	}
+
+	var stdout, stderr strings.Builder
+	args := []string{"--vars", path, "--var", "product=Bar", "--stdin", "--stdout"}
+	input := "(var, product) v(var, version) beta=(var, beta)"
+	if code := run(args, strings.NewReader(input), &stdout, &stderr); code != 0 {
+		t.Fatalf("expected exit code 0, got %d (stderr %q)", code, stderr.String())
+	}
+	if want := "Bar v2 beta=true\n"; stdout.String() != want {
+		t.Fatalf("unexpected output: want %q, got %q", want, stdout.String())
+	}
+
+	if err := os.WriteFile(path, []byte(`{"list": [1, 2]}`), 0644); err != nil {
+		t.Fatalf("failed to rewrite vars file: %v", err)
+	}
+	stdout.Reset()
+	stderr.Reset()
+	if code := run([]string{"--vars", path, "--stdin", "--stdout"}, strings.NewReader("x"), &stdout, &stderr); code != 1 {
+		t.Fatalf("expected exit code 1, got %d", code)
+	}
+	if !strings.Contains(stderr.String(), `"list" must be a string, number or boolean`) {
+		t.Fatalf("unexpected stderr: %q", stderr.String())
+	}
+}
+
+func TestRunMacrosFile(t *testing.T) {
+	t.Parallel()
+
+	path := t.TempDir() + "/macros.txt"
+	if err := os.WriteFile(path, []byte("heading = title then trailing \":\"\n"), 0644); err != nil {
+		t.Fatalf("failed to create macros: %v", err)
+	}
+
+	var stdout, stderr strings.Builder
+	args := []string{"--macros", path, "--stdin", "--stdout"}
+	if code := run(args, strings.NewReader("release notes (heading, 2)"), &stdout, &stderr); code != 0 {
+		t.Fatalf("expected exit code 0, got %d (stderr %q)", code, stderr.String())
+	}
+	if want := "Release Notes:\n"; stdout.String() != want {
+		t.Fatalf("unexpected output: want %q, got %q", want, stdout.String())
+	}
+
+	stdout.Reset()
+	stderr.Reset()
+	if code := run(args, strings.NewReader("(Heading , 2)"), &stdout, &stderr); code != 0 {
+		t.Fatalf("expected exit code 0, got %d", code)
+	}
+	if want := "(Heading , 2)\n"; stdout.String() != want {
+		t.Fatalf("non-canonical macros need --lenient, got %q", stdout.String())
+	}
+
+	if err := os.WriteFile(path, []byte("loud = nope\n"), 0644); err != nil {
+		t.Fatalf("failed to rewrite macros: %v", err)
+	}
+	stderr.Reset()
+	if code := run(args, strings.NewReader("x"), &stdout, &stderr); code != 1 {
+		t.Fatalf("expected exit code 1, got %d", code)
+	}
+	if !strings.Contains(stderr.String(), `macros line 1: macro "loud": unknown marker "nope"`) {
+		t.Fatalf("unexpected stderr: %q", stderr.String())
+	}
+}
+
 func TestResolveInput(t *testing.T) {
 	t.Parallel()
 
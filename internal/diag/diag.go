@@ -0,0 +1,75 @@
+// Package diag describes non-fatal findings reported while formatting text.
+package diag
+
+import "fmt"
+
+// Code classifies a diagnostic so callers can filter or count them.
+type Code string
+
+// Diagnostic codes reported by the pipeline.
+const (
+	// CodeNoTarget marks a marker with no word to act on, e.g. (up) at the
+	// very start of the text.
+	CodeNoTarget Code = "no-target"
+	// CodePartial marks a counted marker that found fewer words than its
+	// count asked for.
+	CodePartial Code = "partial"
+	// CodeInvalidCount marks a marker whose count is zero or negative.
+	CodeInvalidCount Code = "invalid-count"
+	// CodeInvalidNumber marks a numeric marker whose target is not a valid
+	// number in the marker's base.
+	CodeInvalidNumber Code = "invalid-number"
+	// CodeOutOfRange marks a numeric marker whose value cannot be written in
+	// the requested notation, e.g. 0 as a Roman numeral.
+	CodeOutOfRange Code = "out-of-range"
+	// CodeInvalidExpression marks a (calc) marker whose expression is
+	// malformed or cannot be evaluated, e.g. because it divides by zero.
+	CodeInvalidExpression Code = "invalid-expression"
+	// CodeUndefinedVariable marks a (var, name) marker whose variable has no
+	// value.
+	CodeUndefinedVariable Code = "undefined-variable"
+	// CodeUnbalancedBlock marks a (begin x) or (end x) marker without a
+	// partner.
+	CodeUnbalancedBlock Code = "unbalanced-block"
+	// CodeNonCanonical notes a marker accepted by lenient lexing whose
+	// spelling differs from the canonical form.
+	CodeNonCanonical Code = "non-canonical"
+)
+
+// Severity separates markers that could not be applied from remarks about
+// markers that were.
+type Severity int
+
+// Diagnostic severities. The zero value is SeverityWarning.
+const (
+	// SeverityWarning marks a marker that was invalid, unused or only partly
+	// applied.
+	SeverityWarning Severity = iota
+	// SeverityNote marks a marker that was applied but deserves attention,
+	// such as a non-canonical spelling.
+	SeverityNote
+)
+
+func (s Severity) String() string {
+	switch s {
+	case SeverityWarning:
+		return "warning"
+	case SeverityNote:
+		return "note"
+	default:
+		return fmt.Sprintf("Severity(%d)", int(s))
+	}
+}
+
+// Diagnostic describes a marker that could not be applied as written.
+type Diagnostic struct {
+	Offset   int    // byte offset of the marker in the original input
+	Marker   string // marker as written in the source, e.g. "(hex)"
+	Code     Code
+	Severity Severity
+	Msg      string
+}
+
+func (d Diagnostic) String() string {
+	return fmt.Sprintf("byte %d: %s: %s", d.Offset, d.Marker, d.Msg)
+}
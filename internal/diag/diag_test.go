@@ -0,0 +1,13 @@
+package diag
+
+import "testing"
+
+func TestDiagnosticString(t *testing.T) {
+	t.Parallel()
+
+	d := Diagnostic{Offset: 13, Marker: "(up, -1)", Code: CodeInvalidCount, Msg: "count must be positive, got -1"}
+	want := "byte 13: (up, -1): count must be positive, got -1"
+	if got := d.String(); got != want {
+		t.Fatalf("expected %q, got %q", want, got)
+	}
+}
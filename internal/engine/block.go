@@ -0,0 +1,64 @@
+package engine
+
+import (
+	"go-reloaded/internal/diag"
+	"go-reloaded/internal/text"
+)
+
+// matchBlocks pairs every (begin x) marker with the (end x) that closes it
+// and reports markers left unbalanced. Regions nest: an end marker closes the
+// innermost open region of the same type, and regions opened inside it that
+// are still open at that point are reported as unclosed.
+func matchBlocks(ctx *Context) {
+	ctx.blockEnds = make(map[*text.Marker]*text.Marker)
+	var open []int
+
+	for i, node := range ctx.Nodes {
+		if node.Kind != text.NodeMarker || node.Marker == nil {
+			continue
+		}
+		switch node.Marker.Scope {
+		case text.ScopeBlockBegin:
+			open = append(open, i)
+		case text.ScopeBlockEnd:
+			j := len(open) - 1
+			for j >= 0 && ctx.Nodes[open[j]].Marker.Type != node.Marker.Type {
+				j--
+			}
+			if j < 0 {
+				ctx.Report(i, diag.CodeUnbalancedBlock, "no matching (begin %s)", node.Marker.Type)
+				continue
+			}
+			for _, inner := range open[j+1:] {
+				ctx.Report(inner, diag.CodeUnbalancedBlock, "closed by (end %s) before its own (end %s)", node.Marker.Type, ctx.Nodes[inner].Marker.Type)
+			}
+			ctx.blockEnds[ctx.Nodes[open[j]].Marker] = node.Marker
+			open = open[:j]
+		}
+	}
+
+	for _, i := range open {
+		ctx.Report(i, diag.CodeUnbalancedBlock, "no matching (end %s)", ctx.Nodes[i].Marker.Type)
+	}
+}
+
+// blockWords returns the words between the (begin x) marker at markerIndex
+// and its matching (end x). Unbalanced markers select nothing.
+func blockWords(ctx *Context, markerIndex int, m *text.Marker) []int {
+	end, ok := ctx.blockEnds[m]
+	if !ok {
+		return nil
+	}
+
+	var result []int
+	for i := markerIndex + 1; i < len(ctx.Nodes); i++ {
+		node := ctx.Nodes[i]
+		if node.Kind == text.NodeMarker && node.Marker == end {
+			return result
+		}
+		if node.Kind == text.NodeWord {
+			result = append(result, i)
+		}
+	}
+	return result
+}
@@ -0,0 +1,124 @@
+package engine
+
+import (
+	"strings"
+	"testing"
+
+	"go-reloaded/internal/diag"
+	"go-reloaded/internal/text"
+)
+
+func TestApplyMarkersBlocks(t *testing.T) {
+	t.Parallel()
+
+	nodes := []text.Node{
+		word("before"),
+		block(text.MarkerUp, text.ScopeBlockBegin),
+		word("one"), word("two"),
+		block(text.MarkerLow, text.ScopeBlockBegin),
+		word("THREE"),
+		block(text.MarkerLow, text.ScopeBlockEnd),
+		word("four"), word("five"), marker(text.MarkerCap, nil),
+		block(text.MarkerUp, text.ScopeBlockEnd),
+		word("after"),
+	}
+
+	res, err := ApplyMarkersWithOptions(nodes, Options{})
+	if err != nil {
+		t.Fatalf("ApplyMarkersWithOptions returned error: %v", err)
+	}
+
+	checkWord(t, res.Nodes[0], "before")
+	checkWord(t, res.Nodes[2], "ONE")
+	checkWord(t, res.Nodes[3], "TWO")
+	checkWord(t, res.Nodes[5], "three") // inner region wins
+	checkWord(t, res.Nodes[7], "FOUR")
+	checkWord(t, res.Nodes[8], "Five") // later marker wins
+	checkWord(t, res.Nodes[11], "after")
+	if len(res.Diagnostics) != 0 {
+		t.Fatalf("unexpected diagnostics: %v", res.Diagnostics)
+	}
+}
+
+func TestApplyMarkersUnbalancedBlocks(t *testing.T) {
+	t.Parallel()
+
+	nodes := []text.Node{
+		block(text.MarkerLow, text.ScopeBlockEnd),
+		block(text.MarkerUp, text.ScopeBlockBegin),
+		block(text.MarkerCap, text.ScopeBlockBegin),
+		word("one"),
+		block(text.MarkerUp, text.ScopeBlockEnd),
+		block(text.MarkerHex, text.ScopeBlockBegin),
+		word("ff"),
+	}
+	for i := range nodes {
+		if nodes[i].Marker != nil {
+			nodes[i].Marker.Offset = i
+		}
+	}
+
+	res, err := ApplyMarkersWithOptions(nodes, Options{})
+	if err != nil {
+		t.Fatalf("ApplyMarkersWithOptions returned error: %v", err)
+	}
+
+	checkWord(t, res.Nodes[3], "ONE")
+	checkWord(t, res.Nodes[6], "ff")
+
+	wantOffsets := []int{0, 2, 5}
+	if len(res.Diagnostics) != len(wantOffsets) {
+		t.Fatalf("expected %d diagnostics, got %v", len(wantOffsets), res.Diagnostics)
+	}
+	for i, d := range res.Diagnostics {
+		if d.Code != diag.CodeUnbalancedBlock || d.Offset != wantOffsets[i] {
+			t.Fatalf("unexpected diagnostic %d: %+v", i, d)
+		}
+	}
+}
+
+func TestApplyMarkersNumericBlocks(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		kind   text.MarkerType
+		words  []text.Node
+		expect string
+	}{
+		// The sign is folded into the number, removing a node.
+		{text.MarkerHex, []text.Node{punct("-"), word("FF"), space(), word("zz")}, "-255"},
+		// The spelled number is hyphenated, adding nodes.
+		{text.MarkerWords, []text.Node{word("21"), space(), word("42"), space(), word("zz")}, "twenty-one forty-two"},
+	}
+	for _, tc := range tests {
+		begin := block(tc.kind, text.ScopeBlockBegin)
+		begin.Value = "(begin " + string(tc.kind) + ")"
+		begin.Marker.Offset = 2
+		nodes := append([]text.Node{word("x"), space(), begin}, tc.words...)
+		nodes = append(nodes, block(tc.kind, text.ScopeBlockEnd))
+
+		res, err := ApplyMarkersWithOptions(nodes, Options{})
+		if err != nil {
+			t.Fatalf("%s: ApplyMarkersWithOptions returned error: %v", tc.kind, err)
+		}
+		var b strings.Builder
+		for _, node := range res.Nodes[3 : len(res.Nodes)-3] {
+			b.WriteString(node.Value)
+		}
+		if b.String() != tc.expect {
+			t.Fatalf("%s: expected %q, got %q", tc.kind, tc.expect, b.String())
+		}
+		if len(res.Diagnostics) != 1 {
+			t.Fatalf("%s: expected one diagnostic, got %v", tc.kind, res.Diagnostics)
+		}
+		if d := res.Diagnostics[0]; d.Code != diag.CodeInvalidNumber || d.Offset != 2 || d.Marker != begin.Value {
+			t.Fatalf("%s: diagnostic not reported against the region marker: %+v", tc.kind, d)
+		}
+	}
+}
+
+func block(kind text.MarkerType, scope text.MarkerScope) text.Node {
+	n := marker(kind, nil)
+	n.Marker.Scope = scope
+	return n
+}
@@ -0,0 +1,245 @@
+package engine
+
+import (
+	"errors"
+	"fmt"
+	"math/big"
+	"strings"
+
+	"go-reloaded/internal/diag"
+	"go-reloaded/internal/text"
+)
+
+var errDivisionByZero = errors.New("division by zero")
+
+// applyCalc handles (calc) and (calc>). The arithmetic expression written
+// directly before the marker, or after it for (calc>), is replaced by its
+// value. Numbers are integers of any size, division truncates toward zero
+// and a sign directly after a word, as in "x-3", is left alone.
+func applyCalc(ctx *Context, index int, m *text.Marker) error {
+	first, last, ok := expressionSpan(ctx.Nodes, index, m.Forward)
+	if !ok {
+		ctx.Report(index, diag.CodeNoTarget, "no arithmetic expression to evaluate")
+		return nil
+	}
+
+	var b strings.Builder
+	var tokens []string
+	var history []text.Transform
+	for _, node := range ctx.Nodes[first : last+1] {
+		b.WriteString(node.Value)
+		if node.Kind != text.NodeSpace {
+			tokens = append(tokens, node.Value)
+		}
+		history = append(history, node.Transforms...)
+	}
+
+	value, err := evaluate(tokens)
+	if err != nil {
+		ctx.Report(index, diag.CodeInvalidExpression, "cannot evaluate %q: %v", b.String(), err)
+		return nil
+	}
+
+	ctx.Splice(first, last+1, text.Node{Kind: text.NodeWord, Value: value.String(), Transforms: history})
+	ctx.Record(first, m)
+	return nil
+}
+
+// expressionSpan finds the run of numbers, operators, parentheses and spaces
+// next to the marker at index. Operators at the far end of the run and
+// parentheses opened or closed outside it are left out.
+func expressionSpan(nodes []text.Node, index int, forward bool) (int, int, bool) {
+	step := -1
+	if forward {
+		step = 1
+	}
+	// far is the outer end of the run and near the end next to the marker.
+	near, far := index+step, index
+	for i := index + step; i >= 0 && i < len(nodes) && isExpressionNode(nodes[i]); i += step {
+		far = i
+	}
+	if far == index {
+		return 0, 0, false
+	}
+
+	open, close := "(", ")"
+	if forward {
+		open, close = close, open
+	}
+	for {
+		for far != near && nodes[near].Kind == text.NodeSpace {
+			near += step
+		}
+		// A parenthesis closed at the far end never opened inside the run.
+		for far != near && (nodes[far].Kind == text.NodeSpace || isOperator(nodes[far].Value) || nodes[far].Value == close) {
+			far -= step
+		}
+		// Likewise one closed next to the marker, as in "(total 2+3) (calc)".
+		if far != near && nodes[near].Value == close && balance(nodes, far, near, open, close) < 0 {
+			near += step
+			continue
+		}
+		if far == near || nodes[far].Value != open || balance(nodes, far, near, open, close) <= 0 {
+			break
+		}
+		far -= step
+	}
+	if !isNumber(nodes[far]) && nodes[far].Value != open {
+		return 0, 0, false
+	}
+
+	if forward {
+		return near, far, true
+	}
+	return far, near, true
+}
+
+// balance counts opening minus closing parentheses between a and b.
+func balance(nodes []text.Node, a, b int, open, close string) int {
+	if a > b {
+		a, b = b, a
+	}
+	depth := 0
+	for _, node := range nodes[a : b+1] {
+		switch node.Value {
+		case open:
+			depth++
+		case close:
+			depth--
+		}
+	}
+	return depth
+}
+
+func isExpressionNode(node text.Node) bool {
+	switch node.Kind {
+	case text.NodeSpace:
+		return !strings.Contains(node.Value, "\n")
+	case text.NodeWord:
+		return isNumber(node)
+	case text.NodePunct:
+		return isOperator(node.Value) || node.Value == "(" || node.Value == ")"
+	default:
+		return false
+	}
+}
+
+func isNumber(node text.Node) bool {
+	if node.Kind != text.NodeWord {
+		return false
+	}
+	_, ok := parseInteger(node.Value, 10)
+	return ok
+}
+
+func isOperator(s string) bool {
+	return s == "+" || s == "-" || s == "*" || s == "/"
+}
+
+// evaluate computes an expression given as number, operator and parenthesis
+// tokens, with the usual precedence and unary signs.
+func evaluate(tokens []string) (*big.Int, error) {
+	p := &calcParser{tokens: tokens}
+	value, err := p.expr()
+	if err != nil {
+		return nil, err
+	}
+	if p.pos < len(p.tokens) {
+		return nil, fmt.Errorf("unexpected %q", p.tokens[p.pos])
+	}
+	return value, nil
+}
+
+type calcParser struct {
+	tokens []string
+	pos    int
+}
+
+func (p *calcParser) peek() string {
+	if p.pos < len(p.tokens) {
+		return p.tokens[p.pos]
+	}
+	return ""
+}
+
+// expr := term (("+" | "-") term)*
+func (p *calcParser) expr() (*big.Int, error) {
+	left, err := p.term()
+	if err != nil {
+		return nil, err
+	}
+	for op := p.peek(); op == "+" || op == "-"; op = p.peek() {
+		p.pos++
+		right, err := p.term()
+		if err != nil {
+			return nil, err
+		}
+		if op == "+" {
+			left.Add(left, right)
+		} else {
+			left.Sub(left, right)
+		}
+	}
+	return left, nil
+}
+
+// term := unary (("*" | "/") unary)*
+func (p *calcParser) term() (*big.Int, error) {
+	left, err := p.unary()
+	if err != nil {
+		return nil, err
+	}
+	for op := p.peek(); op == "*" || op == "/"; op = p.peek() {
+		p.pos++
+		right, err := p.unary()
+		if err != nil {
+			return nil, err
+		}
+		if op == "*" {
+			left.Mul(left, right)
+			continue
+		}
+		if right.Sign() == 0 {
+			return nil, errDivisionByZero
+		}
+		left.Quo(left, right)
+	}
+	return left, nil
+}
+
+// unary := ("+" | "-") unary | "(" expr ")" | number
+func (p *calcParser) unary() (*big.Int, error) {
+	tok := p.peek()
+	switch {
+	case tok == "":
+		return nil, errors.New("unexpected end of expression")
+	case tok == "+" || tok == "-":
+		p.pos++
+		value, err := p.unary()
+		if err != nil {
+			return nil, err
+		}
+		if tok == "-" {
+			value.Neg(value)
+		}
+		return value, nil
+	case tok == "(":
+		p.pos++
+		value, err := p.expr()
+		if err != nil {
+			return nil, err
+		}
+		if p.peek() != ")" {
+			return nil, errors.New("missing closing parenthesis")
+		}
+		p.pos++
+		return value, nil
+	}
+
+	value, ok := parseInteger(tok, 10)
+	if !ok {
+		return nil, fmt.Errorf("unexpected %q", tok)
+	}
+	p.pos++
+	return value, nil
+}
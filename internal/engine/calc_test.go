@@ -0,0 +1,121 @@
+package engine
+
+import (
+	"strings"
+	"testing"
+
+	"go-reloaded/internal/diag"
+	"go-reloaded/internal/text"
+)
+
+func TestEvaluate(t *testing.T) {
+	t.Parallel()
+
+	tests := map[string]string{
+		"2 + 3":         "5",
+		"2 + 3 * 4":     "14",
+		"( 2 + 3 ) * 4": "20",
+		"7 / 2":         "3",
+		"- 7 / 2":       "-3",
+		"10 - - 3":      "13",
+		"1_000 * 1_000": "1000000",
+		"99999999999999999999 * 99999999999999999999": "9999999999999999999800000000000000000001",
+	}
+	for in, want := range tests {
+		got, err := evaluate(strings.Fields(in))
+		if err != nil {
+			t.Errorf("evaluate(%q) returned error: %v", in, err)
+			continue
+		}
+		if got.String() != want {
+			t.Errorf("evaluate(%q) = %s, want %s", in, got, want)
+		}
+	}
+
+	failures := map[string]string{
+		"5 / 0":         "division by zero",
+		"5 / ( 2 - 2 )": "division by zero",
+		"2 +":           "unexpected end of expression",
+		"( 2 + 3":       "missing closing parenthesis",
+		"2 3":           `unexpected "3"`,
+		") 2":           `unexpected ")"`,
+	}
+	for in, want := range failures {
+		if _, err := evaluate(strings.Fields(in)); err == nil || err.Error() != want {
+			t.Errorf("evaluate(%q) error = %v, want %q", in, err, want)
+		}
+	}
+}
+
+func TestApplyMarkersCalc(t *testing.T) {
+	t.Parallel()
+
+	calc := text.Node{Kind: text.NodeMarker, Marker: &text.Marker{Type: text.MarkerCalc}}
+	calcForward := text.Node{Kind: text.NodeMarker, Marker: &text.Marker{Type: text.MarkerCalc, Forward: true}}
+	nodes := []text.Node{
+		word("total"), space(), punct("("), word("2"), space(), punct("+"), space(), word("3"), punct(")"), punct("*"), word("4"),
+		space(), calc,
+		space(), word("x"), punct("+"), word("1"), calc,
+		space(), calcForward, space(), word("6"), punct("/"), word("0"), space(), word("end"),
+	}
+
+	res, err := ApplyMarkersWithOptions(nodes, Options{})
+	if err != nil {
+		t.Fatalf("ApplyMarkersWithOptions returned error: %v", err)
+	}
+	got := res.Nodes
+
+	checkWord(t, got[0], "total")
+	checkWord(t, got[2], "20")
+	// A sign directly after a word is not part of the expression.
+	checkWord(t, got[6], "x")
+	if got[7].Value != "+" {
+		t.Fatalf("expected the operator after a word to stay, got %q", got[7].Value)
+	}
+	checkWord(t, got[8], "1")
+	checkWord(t, got[13], "6")
+
+	if len(res.Diagnostics) != 1 || res.Diagnostics[0].Code != diag.CodeInvalidExpression {
+		t.Fatalf("expected one invalid-expression diagnostic, got %v", res.Diagnostics)
+	}
+	if !strings.Contains(res.Diagnostics[0].Msg, "division by zero") {
+		t.Fatalf("unexpected message: %q", res.Diagnostics[0].Msg)
+	}
+}
+
+func TestApplyMarkersCalcInsideParentheses(t *testing.T) {
+	t.Parallel()
+
+	calc := text.Node{Kind: text.NodeMarker, Marker: &text.Marker{Type: text.MarkerCalc}}
+	nodes := []text.Node{
+		punct("("), word("total"), space(), word("2"), punct("+"), word("3"), punct(")"), space(), calc,
+	}
+
+	res, err := ApplyMarkersWithOptions(nodes, Options{})
+	if err != nil {
+		t.Fatalf("ApplyMarkersWithOptions returned error: %v", err)
+	}
+	got := res.Nodes
+
+	checkWord(t, got[1], "total")
+	checkWord(t, got[3], "5")
+	if got[4].Value != ")" {
+		t.Fatalf("expected the closing parenthesis to stay, got %q", got[4].Value)
+	}
+	if len(res.Diagnostics) != 0 {
+		t.Fatalf("unexpected diagnostics: %v", res.Diagnostics)
+	}
+}
+
+func TestApplyMarkersCalcWithoutExpression(t *testing.T) {
+	t.Parallel()
+
+	nodes := []text.Node{word("hello"), space(), {Kind: text.NodeMarker, Marker: &text.Marker{Type: text.MarkerCalc}}}
+	res, err := ApplyMarkersWithOptions(nodes, Options{})
+	if err != nil {
+		t.Fatalf("ApplyMarkersWithOptions returned error: %v", err)
+	}
+	if len(res.Diagnostics) != 1 || res.Diagnostics[0].Code != diag.CodeNoTarget {
+		t.Fatalf("expected a no-target diagnostic, got %v", res.Diagnostics)
+	}
+}
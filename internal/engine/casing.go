@@ -0,0 +1,146 @@
+package engine
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"unicode"
+)
+
+// caser performs the case mappings behind (up), (low), (cap) and (title) for
+// one locale. The zero value applies the locale-independent Unicode rules.
+type caser struct {
+	special    unicode.SpecialCase // Turkish and Azeri dotted and dotless i
+	lithuanian bool                // keep the dot above an accented i or j
+}
+
+// combiningDotAbove is U+0307, which Lithuanian writes over a lower-case i or
+// j that carries a further accent so the dot is not lost.
+const combiningDotAbove = '̇'
+
+var localePattern = regexp.MustCompile(`^([A-Za-z]{2,3})(?:[-_][A-Za-z0-9]{1,8})*$`)
+
+// newCaser returns the caser for a BCP 47 style locale such as "tr" or
+// "lt-LT". Languages without special casing rules use the Unicode defaults.
+func newCaser(locale string) (caser, error) {
+	if locale == "" {
+		return caser{}, nil
+	}
+	match := localePattern.FindStringSubmatch(locale)
+	if match == nil {
+		return caser{}, fmt.Errorf("invalid locale %q", locale)
+	}
+	switch strings.ToLower(match[1]) {
+	case "tr":
+		return caser{special: unicode.TurkishCase}, nil
+	case "az":
+		return caser{special: unicode.AzeriCase}, nil
+	case "lt":
+		return caser{lithuanian: true}, nil
+	default:
+		return caser{}, nil
+	}
+}
+
+// upper maps s to upper case. Unlike strings.ToUpper it expands ß to SS.
+func (c caser) upper(s string) string {
+	var b strings.Builder
+	var prev rune
+	for _, r := range s {
+		switch {
+		case r == 'ß':
+			b.WriteString("SS")
+		case c.lithuanian && r == combiningDotAbove && unicode.Is(unicode.Soft_Dotted, prev):
+			// The capital letter has no dot to preserve.
+		default:
+			b.WriteRune(c.toUpper(r))
+		}
+		prev = r
+	}
+	return b.String()
+}
+
+// lower maps s to lower case, using final sigma at the end of a word.
+func (c caser) lower(s string) string {
+	return c.lowerFrom([]rune(s), 0)
+}
+
+// capitalize title-cases the first letter of s and lower-cases the rest, so
+// the digraph "ǆ" becomes "ǅ" rather than "Ǆ".
+func (c caser) capitalize(s string) string {
+	runes := []rune(s)
+	if len(runes) == 0 {
+		return s
+	}
+
+	var b strings.Builder
+	if runes[0] == 'ß' {
+		b.WriteString("Ss")
+	} else {
+		b.WriteRune(c.toTitle(runes[0]))
+	}
+	rest := 1
+	if c.lithuanian && len(runes) > 1 && runes[1] == combiningDotAbove && unicode.Is(unicode.Soft_Dotted, runes[0]) {
+		rest = 2
+	}
+	b.WriteString(c.lowerFrom(runes, rest))
+	return b.String()
+}
+
+// lowerFrom lower-cases runes[from:], using the runes before from as context.
+func (c caser) lowerFrom(runes []rune, from int) string {
+	var b strings.Builder
+	for i := from; i < len(runes); i++ {
+		r := runes[i]
+		switch {
+		case r == 'Σ' && i > 0 && unicode.IsLetter(runes[i-1]) && (i+1 == len(runes) || !unicode.IsLetter(runes[i+1])):
+			b.WriteRune('ς')
+		case c.lithuanian:
+			b.WriteString(lithuanianLower(runes, i, c.toLower(r)))
+		default:
+			b.WriteRune(c.toLower(r))
+		}
+	}
+	return b.String()
+}
+
+// lithuanianLower returns the lower-case spelling of runes[i], adding a dot
+// above to i, j and į when another accent follows and expanding the
+// precomposed Ì, Í and Ĩ the same way.
+func lithuanianLower(runes []rune, i int, lower rune) string {
+	switch runes[i] {
+	case 'Ì':
+		return "i̇̀"
+	case 'Í':
+		return "i̇́"
+	case 'Ĩ':
+		return "i̇̃"
+	case 'I', 'J', 'Į':
+		// U+0300 to U+0314 are the combining accents written above a letter.
+		if i+1 < len(runes) && runes[i+1] >= '̀' && runes[i+1] <= '̔' {
+			return string(lower) + string(combiningDotAbove)
+		}
+	}
+	return string(lower)
+}
+
+func (c caser) toUpper(r rune) rune {
+	if c.special != nil {
+		return c.special.ToUpper(r)
+	}
+	return unicode.ToUpper(r)
+}
+
+func (c caser) toLower(r rune) rune {
+	if c.special != nil {
+		return c.special.ToLower(r)
+	}
+	return unicode.ToLower(r)
+}
+
+func (c caser) toTitle(r rune) rune {
+	if c.special != nil {
+		return c.special.ToTitle(r)
+	}
+	return unicode.ToTitle(r)
+}
@@ -0,0 +1,62 @@
+package engine
+
+import (
+	"testing"
+
+	"go-reloaded/internal/text"
+)
+
+func TestCaserMappings(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		locale string
+		fn     func(caser, string) string
+		in     string
+		want   string
+	}{
+		{"", caser.upper, "istanbul", "ISTANBUL"},
+		{"tr", caser.upper, "istanbul", "İSTANBUL"},
+		{"tr-TR", caser.lower, "ILIK", "ılık"},
+		{"az", caser.capitalize, "iki", "İki"},
+		{"", caser.capitalize, "ǆungla", "ǅungla"},
+		{"", caser.upper, "ǆungla", "ǄUNGLA"},
+		{"", caser.upper, "straße", "STRASSE"},
+		{"", caser.capitalize, "ßtraße", "Sstraße"},
+		{"", caser.lower, "ΟΔΟΣ", "οδος"},
+		{"", caser.lower, "ΣΟΦΙΑ", "σοφια"},
+		{"lt", caser.lower, "Ì", "i\u0307\u0300"},
+		{"lt", caser.lower, "J\u0301", "j\u0307\u0301"},
+		{"lt", caser.lower, "IS", "is"},
+		{"lt", caser.upper, "i\u0307\u0300", "I\u0300"},
+		{"lt_LT", caser.capitalize, "i\u0307\u0303s", "I\u0303s"},
+	}
+
+	for _, tt := range tests {
+		c, err := newCaser(tt.locale)
+		if err != nil {
+			t.Fatalf("newCaser(%q) returned error: %v", tt.locale, err)
+		}
+		if got := tt.fn(c, tt.in); got != tt.want {
+			t.Errorf("locale %q: %q mapped to %q, want %q", tt.locale, tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestApplyMarkersLocale(t *testing.T) {
+	t.Parallel()
+
+	two := 2
+	nodes := []text.Node{word("izmir"), space(), word("ilik"), marker(text.MarkerTitle, &two)}
+
+	res, err := ApplyMarkersWithOptions(nodes, Options{Locale: "tr"})
+	if err != nil {
+		t.Fatalf("ApplyMarkersWithOptions returned error: %v", err)
+	}
+	checkWord(t, res.Nodes[0], "İzmir")
+	checkWord(t, res.Nodes[2], "İlik")
+
+	if _, err := ApplyMarkersWithOptions(nodes, Options{Locale: "not a locale"}); err == nil {
+		t.Fatal("expected an error for an invalid locale")
+	}
+}
@@ -0,0 +1,96 @@
+package engine
+
+import "go-reloaded/internal/text"
+
+// joinFunc reports whether the words at nodes[a] and nodes[b], a < b, with no
+// word between them, count as one unit for a marker's count.
+type joinFunc func(nodes []text.Node, a, b int) bool
+
+// isCompoundJoiner reports whether nodes[i] is a "-" or "/" written directly
+// between two words, as in "state-of-the-art" or "and/or".
+func isCompoundJoiner(nodes []text.Node, i int) bool {
+	if i <= 0 || i >= len(nodes)-1 || nodes[i].Kind != text.NodePunct {
+		return false
+	}
+	if nodes[i].Value != "-" && nodes[i].Value != "/" {
+		return false
+	}
+	return nodes[i-1].Kind == text.NodeWord && nodes[i+1].Kind == text.NodeWord
+}
+
+// compoundJoin joins the parts of a hyphen- or slash-joined compound.
+func compoundJoin(nodes []text.Node, a, b int) bool {
+	return b == a+2 && isCompoundJoiner(nodes, a+1)
+}
+
+// hyphenJoin joins words written directly either side of a hyphen.
+func hyphenJoin(nodes []text.Node, a, b int) bool {
+	return compoundJoin(nodes, a, b) && nodes[a+1].Value == "-"
+}
+
+// unitSpans groups the selected words into the [first, last] node ranges of
+// the units join links them into. A nil join makes every word its own unit.
+func unitSpans(nodes []text.Node, words []int, join joinFunc) [][2]int {
+	var spans [][2]int
+	for i, idx := range words {
+		if join != nil && i > 0 && join(nodes, words[i-1], idx) {
+			spans[len(spans)-1][1] = idx
+			continue
+		}
+		spans = append(spans, [2]int{idx, idx})
+	}
+	return spans
+}
+
+// eitherJoin joins words that either f or g joins.
+func eitherJoin(f, g joinFunc) joinFunc {
+	return func(nodes []text.Node, a, b int) bool {
+		return f(nodes, a, b) || g(nodes, a, b)
+	}
+}
+
+// findPreviousUnits is findPreviousWord counting words that join links
+// together as a single unit. It returns the indices of every word, in order,
+// and the number of units found.
+func findPreviousUnits(nodes []text.Node, markerIndex int, count int, join joinFunc) ([]int, int) {
+	var result []int
+	found, last := 0, -1
+	for i := markerIndex - 1; i >= 0; i-- {
+		if nodes[i].Kind != text.NodeWord {
+			continue
+		}
+		if last < 0 || !join(nodes, i, last) {
+			if found == count {
+				break
+			}
+			found++
+		}
+		result = append(result, i)
+		last = i
+	}
+
+	for i, j := 0, len(result)-1; i < j; i, j = i+1, j-1 {
+		result[i], result[j] = result[j], result[i]
+	}
+	return result, found
+}
+
+// findNextUnits is the forward counterpart of findPreviousUnits.
+func findNextUnits(nodes []text.Node, markerIndex int, count int, join joinFunc) ([]int, int) {
+	var result []int
+	found, last := 0, -1
+	for i := markerIndex + 1; i < len(nodes); i++ {
+		if nodes[i].Kind != text.NodeWord {
+			continue
+		}
+		if last < 0 || !join(nodes, last, i) {
+			if found == count {
+				break
+			}
+			found++
+		}
+		result = append(result, i)
+		last = i
+	}
+	return result, found
+}
@@ -0,0 +1,78 @@
+package engine
+
+import (
+	"testing"
+
+	"go-reloaded/internal/text"
+)
+
+func TestApplyMarkersCompounds(t *testing.T) {
+	t.Parallel()
+
+	two, three := 2, 3
+	nodes := []text.Node{
+		word("a"), space(), word("state"), punct("-"), word("of"), punct("-"), word("the"), punct("-"), word("art"),
+		marker(text.MarkerCap, &two),
+		space(), word("the"), space(), word("state"), punct("-"), word("of"), punct("-"), word("the"), punct("-"), word("art"),
+		space(), word("method"),
+		marker(text.MarkerTitle, &three),
+		space(), word("and"), punct("/"), word("or"),
+		marker(text.MarkerUp, nil),
+	}
+
+	res, err := ApplyMarkersWithOptions(nodes, Options{Compounds: true})
+	if err != nil {
+		t.Fatalf("ApplyMarkersWithOptions returned error: %v", err)
+	}
+	got := res.Nodes
+
+	checkWord(t, got[0], "A")
+	for i, want := range map[int]string{2: "State", 4: "Of", 6: "The", 8: "Art"} {
+		checkWord(t, got[i], want)
+	}
+	for i, want := range map[int]string{11: "The", 13: "State", 15: "of", 17: "the", 19: "Art", 21: "Method"} {
+		checkWord(t, got[i], want)
+	}
+	checkWord(t, got[24], "AND")
+	checkWord(t, got[26], "OR")
+	if len(res.Diagnostics) != 0 {
+		t.Fatalf("unexpected diagnostics: %v", res.Diagnostics)
+	}
+}
+
+func TestCompoundsNeedDirectJoin(t *testing.T) {
+	t.Parallel()
+
+	nodes := []text.Node{
+		word("well"), punct("-"), word("known"), space(), punct("-"), space(), word("fact"),
+	}
+
+	words, found := findPreviousUnits(nodes, len(nodes), 2, compoundJoin)
+	if found != 2 || len(words) != 3 || words[0] != 0 || words[2] != 6 {
+		t.Fatalf("unexpected selection %v (%d compounds)", words, found)
+	}
+
+	words, found = findNextUnits(nodes, -1, 1, compoundJoin)
+	if found != 1 || len(words) != 2 || words[1] != 2 {
+		t.Fatalf("unexpected forward selection %v (%d compounds)", words, found)
+	}
+}
+
+func TestCompoundsPartialCountsUnits(t *testing.T) {
+	t.Parallel()
+
+	three := 3
+	nodes := []text.Node{
+		word("x"), punct("-"), word("ray"), marker(text.MarkerUp, &three),
+	}
+
+	res, err := ApplyMarkersWithOptions(nodes, Options{Compounds: true})
+	if err != nil {
+		t.Fatalf("ApplyMarkersWithOptions returned error: %v", err)
+	}
+	checkWord(t, res.Nodes[0], "X")
+	checkWord(t, res.Nodes[2], "RAY")
+	if len(res.Diagnostics) != 1 || res.Diagnostics[0].Msg != "only 1 of 3 words available" {
+		t.Fatalf("unexpected diagnostics: %v", res.Diagnostics)
+	}
+}
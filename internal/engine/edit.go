@@ -0,0 +1,100 @@
+package engine
+
+import (
+	"strings"
+
+	"go-reloaded/internal/diag"
+	"go-reloaded/internal/text"
+)
+
+// applyDelete handles (del) and (del, n). The selected words are removed
+// together with the spaces or hyphens joining them, plus one space beside
+// each removed run so no doubled space is left behind.
+func applyDelete(ctx *Context, index int, m *text.Marker) error {
+	words := targetWords(ctx, index, m)
+	// Remove from the end so the indices of earlier runs stay valid.
+	for end := len(words) - 1; end >= 0; {
+		start := end
+		for start > 0 && mergeable(ctx.Nodes, words[start-1], words[start]) {
+			start--
+		}
+
+		from, to := words[start], words[end]+1
+		switch {
+		case from > 0 && isInlineSpace(ctx.Nodes[from-1]):
+			from--
+		case to < len(ctx.Nodes) && isInlineSpace(ctx.Nodes[to]):
+			to++
+		}
+		ctx.Splice(from, to, markerNodes(ctx.Nodes[from:to])...)
+		end = start - 1
+	}
+	return nil
+}
+
+// applySwap handles (swap): the two previous words, or the next two for
+// (swap>), exchange places. With --compounds a whole compound counts as one
+// word. Each word keeps its own transform history.
+func applySwap(ctx *Context, index int, m *text.Marker) error {
+	two := 2
+	pair := *m
+	pair.Count = &two
+	if end, ok := ctx.blockEnds[m]; ok {
+		ctx.blockEnds[&pair] = end
+	}
+	words := targetWords(ctx, index, &pair)
+	units := unitSpans(ctx.Nodes, words, wordJoin(ctx, m))
+	if len(units) != 2 {
+		// Counted selections have already been reported; a region can hold
+		// any number of words.
+		if m.Scope == text.ScopeBlockBegin && len(units) > 0 {
+			ctx.Report(index, diag.CodeInvalidCount, "swap needs exactly 2 words, the region has %d", len(units))
+		}
+		return nil
+	}
+
+	a, b := units[0], units[1]
+	var repl []text.Node
+	repl = append(repl, ctx.Nodes[b[0]:b[1]+1]...)
+	repl = append(repl, ctx.Nodes[a[1]+1:b[0]]...)
+	repl = append(repl, ctx.Nodes[a[0]:a[1]+1]...)
+	ctx.Splice(a[0], b[1]+1, repl...)
+	for i, node := range repl {
+		if node.Kind == text.NodeWord {
+			ctx.Record(a[0]+i, m)
+		}
+	}
+	return nil
+}
+
+// applyDuplicate handles (dup) and (dup, n): the selected words, and
+// whatever lies between them, are repeated once after a space.
+func applyDuplicate(ctx *Context, index int, m *text.Marker) error {
+	words := targetWords(ctx, index, m)
+	if len(words) == 0 {
+		return nil
+	}
+
+	first, last := words[0], words[len(words)-1]
+	copies := make([]text.Node, last+1-first)
+	copy(copies, ctx.Nodes[first:last+1])
+	space := text.Node{Kind: text.NodeSpace, Value: " "}
+
+	at := last + 1
+	repl := append([]text.Node{space}, copies...)
+	if m.Forward {
+		at = first
+		repl = append(copies, space)
+	}
+	ctx.Splice(at, at, repl...)
+	for i, node := range repl {
+		if node.Kind == text.NodeWord {
+			ctx.Record(at+i, m)
+		}
+	}
+	return nil
+}
+
+func isInlineSpace(node text.Node) bool {
+	return node.Kind == text.NodeSpace && !strings.Contains(node.Value, "\n")
+}
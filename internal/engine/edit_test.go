@@ -0,0 +1,151 @@
+package engine
+
+import (
+	"strings"
+	"testing"
+
+	"go-reloaded/internal/diag"
+	"go-reloaded/internal/text"
+)
+
+func TestApplyMarkersDelete(t *testing.T) {
+	t.Parallel()
+
+	two := 2
+	nodes := []text.Node{
+		word("keep"), space(), word("one"), space(), word("two"), space(), marker(text.MarkerDelete, &two),
+		space(), word("end"),
+	}
+
+	got, err := ApplyMarkers(nodes)
+	if err != nil {
+		t.Fatalf("ApplyMarkers returned error: %v", err)
+	}
+	if len(got) != 5 {
+		t.Fatalf("unexpected nodes after delete: %v", got)
+	}
+	checkWord(t, got[0], "keep")
+	if got[1].Kind != text.NodeSpace || got[2].Kind != text.NodeMarker || got[3].Kind != text.NodeSpace {
+		t.Fatalf("expected one space before the marker, got %v", got)
+	}
+	checkWord(t, got[4], "end")
+}
+
+func TestApplyMarkersDeleteKeepsPunctuation(t *testing.T) {
+	t.Parallel()
+
+	two := 2
+	nodes := []text.Node{
+		word("one"), space(), word("two"), punct(","), space(), word("three"), space(), marker(text.MarkerDelete, &two),
+	}
+
+	got, err := ApplyMarkers(nodes)
+	if err != nil {
+		t.Fatalf("ApplyMarkers returned error: %v", err)
+	}
+	if len(got) != 4 {
+		t.Fatalf("unexpected nodes after delete: %v", got)
+	}
+	checkWord(t, got[0], "one")
+	if got[1].Value != "," || got[2].Kind != text.NodeSpace || got[3].Kind != text.NodeMarker {
+		t.Fatalf("expected the comma to survive, got %v", got)
+	}
+}
+
+func TestApplyMarkersSwap(t *testing.T) {
+	t.Parallel()
+
+	nodes := []text.Node{
+		word("world"), space(), word("hello"), space(), marker(text.MarkerSwap, nil),
+		space(), forward(text.MarkerSwap, nil), space(), word("b"), punct(","), space(), word("a"),
+	}
+
+	res, err := ApplyMarkersWithOptions(nodes, Options{})
+	if err != nil {
+		t.Fatalf("ApplyMarkersWithOptions returned error: %v", err)
+	}
+	checkWord(t, res.Nodes[0], "hello")
+	checkWord(t, res.Nodes[2], "world")
+	checkWord(t, res.Nodes[8], "a")
+	checkWord(t, res.Nodes[11], "b")
+	if len(res.Diagnostics) != 0 {
+		t.Fatalf("unexpected diagnostics: %v", res.Diagnostics)
+	}
+
+	res, err = ApplyMarkersWithOptions([]text.Node{word("alone"), marker(text.MarkerSwap, nil)}, Options{})
+	if err != nil {
+		t.Fatalf("ApplyMarkersWithOptions returned error: %v", err)
+	}
+	checkWord(t, res.Nodes[0], "alone")
+	if len(res.Diagnostics) != 1 || res.Diagnostics[0].Code != diag.CodePartial {
+		t.Fatalf("expected a partial diagnostic, got %v", res.Diagnostics)
+	}
+}
+
+func TestApplyMarkersSwapUnits(t *testing.T) {
+	t.Parallel()
+
+	nodes := []text.Node{
+		word("state"), punct("-"), word("of"), punct("-"), word("art"), space(), word("design"),
+		space(), marker(text.MarkerSwap, nil),
+	}
+	res, err := ApplyMarkersWithOptions(nodes, Options{Compounds: true})
+	if err != nil {
+		t.Fatalf("ApplyMarkersWithOptions returned error: %v", err)
+	}
+	var b strings.Builder
+	for _, node := range res.Nodes[:7] {
+		b.WriteString(node.Value)
+	}
+	if want := "design state-of-art"; b.String() != want {
+		t.Fatalf("expected %q, got %q", want, b.String())
+	}
+	if len(res.Diagnostics) != 0 {
+		t.Fatalf("unexpected diagnostics: %v", res.Diagnostics)
+	}
+
+	nodes = []text.Node{
+		block(text.MarkerSwap, text.ScopeBlockBegin), word("aa"), space(), word("bb"), block(text.MarkerSwap, text.ScopeBlockEnd),
+		block(text.MarkerSwap, text.ScopeBlockBegin), word("cc"), block(text.MarkerSwap, text.ScopeBlockEnd),
+	}
+	res, err = ApplyMarkersWithOptions(nodes, Options{})
+	if err != nil {
+		t.Fatalf("ApplyMarkersWithOptions returned error: %v", err)
+	}
+	checkWord(t, res.Nodes[1], "bb")
+	checkWord(t, res.Nodes[3], "aa")
+	if len(res.Diagnostics) != 1 || res.Diagnostics[0].Code != diag.CodeInvalidCount {
+		t.Fatalf("expected one invalid-count diagnostic, got %v", res.Diagnostics)
+	}
+}
+
+func TestApplyMarkersDuplicate(t *testing.T) {
+	t.Parallel()
+
+	two := 2
+	nodes := []text.Node{
+		word("bye"), punct(","), space(), word("now"), marker(text.MarkerDup, &two),
+		space(), forward(text.MarkerDup, nil), space(), word("go"),
+	}
+
+	got, err := ApplyMarkers(nodes)
+	if err != nil {
+		t.Fatalf("ApplyMarkers returned error: %v", err)
+	}
+
+	want := []string{"bye", ",", " ", "now", " ", "bye", ",", " ", "now", "", " ", "", " ", "go", " ", "go"}
+	if len(got) != len(want) {
+		t.Fatalf("unexpected nodes after dup: %v", got)
+	}
+	for i, w := range want {
+		if got[i].Kind != text.NodeMarker && got[i].Value != w {
+			t.Fatalf("node %d: want %q, got %q", i, w, got[i].Value)
+		}
+	}
+	if last, ok := got[5].LastTransform(); !ok || last.Type != text.MarkerDup {
+		t.Fatalf("expected the copy to record (dup), got %v", got[5].Transforms)
+	}
+	if len(got[0].Transforms) != 0 {
+		t.Fatalf("expected the original to stay untouched, got %v", got[0].Transforms)
+	}
+}
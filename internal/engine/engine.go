@@ -3,98 +3,208 @@ package engine
 
 import (
 	"fmt"
-	"strconv"
-	"strings"
+	"sort"
 
+	"go-reloaded/internal/diag"
 	"go-reloaded/internal/text"
 )
 
+// Options configures ApplyMarkersWithOptions.
+type Options struct {
+	// Registry supplies marker handlers. Nil means the built-in markers.
+	Registry *Registry
+	// Locale selects locale-specific case mappings, e.g. "tr" for the
+	// Turkish dotted and dotless i. Empty means the Unicode defaults.
+	Locale string
+	// Protected lists words whose spelling case markers keep, such as
+	// "NASA" or "iPhone". Nil means DefaultProtectedWords; pass an empty
+	// dictionary to protect nothing.
+	Protected ProtectedWords
+	// Vars holds the values that (var, name) markers expand to.
+	Vars map[string]string
+	// Compounds makes counted markers treat hyphen- and slash-joined words
+	// such as "state-of-the-art" as one word. Casing still applies per part.
+	Compounds bool
+}
+
+// Result is the outcome of ApplyMarkersWithOptions.
+type Result struct {
+	Nodes       []text.Node
+	Diagnostics []diag.Diagnostic
+}
+
 // ApplyMarkers walks the parsed node list and applies marker directives to
 // previous words. It returns a new slice, leaving the original untouched.
 func ApplyMarkers(nodes []text.Node) ([]text.Node, error) {
+	res, err := ApplyMarkersWithOptions(nodes, Options{})
+	if err != nil {
+		return nil, err
+	}
+	return res.Nodes, nil
+}
+
+// ApplyMarkersWithOptions is ApplyMarkers with caller-supplied configuration.
+// It also reports markers that could not be applied.
+func ApplyMarkersWithOptions(nodes []text.Node, opts Options) (Result, error) {
+	registry := opts.Registry
+	if registry == nil {
+		registry = defaultRegistry
+	}
+
+	caser, err := newCaser(opts.Locale)
+	if err != nil {
+		return Result{}, err
+	}
+
+	protected := opts.Protected
+	if protected == nil {
+		protected = defaultProtected
+	}
+
 	out := make([]text.Node, len(nodes))
 	copy(out, nodes)
+	ctx := &Context{
+		Nodes:     out,
+		caser:     caser,
+		protected: protected,
+		vars:      opts.Vars,
+		compounds: opts.Compounds,
+	}
+	if err := expandVariables(ctx); err != nil {
+		return Result{}, err
+	}
+	matchBlocks(ctx)
 
-	for i := range out {
-		node := out[i]
+	for ctx.cursor = 0; ctx.cursor < len(ctx.Nodes); ctx.cursor++ {
+		node := ctx.Nodes[ctx.cursor]
 		if node.Kind != text.NodeMarker || node.Marker == nil {
 			continue
 		}
+		if node.Marker.Scope == text.ScopeBlockEnd {
+			// Applied together with the matching (begin ...) marker.
+			continue
+		}
 
-		switch node.Marker.Type {
-		case text.MarkerHex:
-			if err := applyNumericConversion(out, i, 16); err != nil {
-				return nil, err
-			}
-		case text.MarkerBin:
-			if err := applyNumericConversion(out, i, 2); err != nil {
-				return nil, err
-			}
-		case text.MarkerUp:
-			markerType := text.MarkerUp
-			applyWordTransform(out, i, node.Marker.Count, strings.ToUpper, &markerType)
-		case text.MarkerLow:
-			markerType := text.MarkerLow
-			applyWordTransform(out, i, node.Marker.Count, strings.ToLower, &markerType)
-		case text.MarkerCap:
-			markerType := text.MarkerCap
-			applyWordTransform(out, i, node.Marker.Count, capitalizeWord, &markerType)
-		default:
-			return nil, fmt.Errorf("unknown marker type: %s", node.Marker.Type)
+		handler, ok := registry.handler(node.Marker.Type)
+		if !ok {
+			return Result{}, fmt.Errorf("unknown marker type: %s", node.Marker.Type)
+		}
+		if err := handler(ctx, ctx.cursor, node.Marker); err != nil {
+			return Result{}, err
 		}
 	}
 
-	return out, nil
+	sort.SliceStable(ctx.Diagnostics, func(i, j int) bool {
+		return ctx.Diagnostics[i].Offset < ctx.Diagnostics[j].Offset
+	})
+	return Result{Nodes: ctx.Nodes, Diagnostics: ctx.Diagnostics}, nil
 }
 
-func applyNumericConversion(nodes []text.Node, markerIndex int, base int) error {
-	wordIdx := findPreviousWord(nodes, markerIndex, 1)
-	if len(wordIdx) == 0 {
+func caseHandler(transform func(caser, string) string) Handler {
+	return func(ctx *Context, index int, m *text.Marker) error {
+		applyWordTransform(ctx, index, m, func(s string) string { return transform(ctx.caser, s) })
 		return nil
 	}
-	prev := nodes[wordIdx[0]]
-	num := prev.Value
-
-	var parsed int64
-	var err error
-	if base == 16 {
-		parsed, err = strconv.ParseInt(num, base, 64)
-	} else {
-		if validBinary(num) {
-			parsed, err = strconv.ParseInt(num, base, 64)
-		} else {
-			return nil
-		}
-	}
-	if err != nil {
-		return nil
+}
+
+func applyWordTransform(ctx *Context, markerIndex int, m *text.Marker, transform func(string) string) {
+	for _, idx := range targetWords(ctx, markerIndex, m) {
+		word := ctx.Nodes[idx].Value
+		ctx.Nodes[idx].Value = ctx.protected.protect(word, transform(word))
+		ctx.Record(idx, m)
 	}
-	nodes[wordIdx[0]].Value = strconv.FormatInt(parsed, 10)
-	return nil
 }
 
-func validBinary(s string) bool {
-	for _, r := range s {
-		if r != '0' && r != '1' {
-			return false
+// targetWords resolves the words a marker acts on: the previous n words, the
+// next n words for forward markers such as (up>, 2), the surrounding sentence
+// or paragraph, or every word of a (begin ...) region. Markers that select
+// nothing, or fewer words than their count asks for, are reported.
+func targetWords(ctx *Context, markerIndex int, m *text.Marker) []int {
+	nodes := ctx.Nodes
+	var words []int
+	switch m.Scope {
+	case text.ScopeBlockBegin:
+		if _, ok := ctx.blockEnds[m]; !ok {
+			// Already reported by matchBlocks.
+			return nil
+		}
+		words = blockWords(ctx, markerIndex, m)
+	case text.ScopeSentence:
+		words = spanWords(nodes, markerIndex, m.Forward, isSentenceBoundary)
+	case text.ScopeParagraph:
+		words = spanWords(nodes, markerIndex, m.Forward, text.IsParagraphBreak)
+	default:
+		count := 1
+		if m.Count != nil {
+			count = *m.Count
+		}
+		if count <= 0 {
+			ctx.Report(markerIndex, diag.CodeInvalidCount, "count must be positive, got %d", count)
+			return nil
+		}
+		found := 0
+		join := wordJoin(ctx, m)
+		switch {
+		case join != nil && m.Forward:
+			words, found = findNextUnits(nodes, markerIndex, count, join)
+		case join != nil:
+			words, found = findPreviousUnits(nodes, markerIndex, count, join)
+		case m.Forward:
+			words = findNextWord(nodes, markerIndex, count)
+			found = len(words)
+		default:
+			words = findPreviousWord(nodes, markerIndex, count)
+			found = len(words)
 		}
+		if found > 0 && found < count {
+			ctx.Report(markerIndex, diag.CodePartial, "only %d of %d words available", found, count)
+		}
+	}
+
+	if len(words) == 0 {
+		ctx.Report(markerIndex, diag.CodeNoTarget, "no %s to act on", targetDescription(m))
 	}
-	return len(s) > 0
+	return words
 }
 
-func applyWordTransform(nodes []text.Node, markerIndex int, countPtr *int, transform func(string) string, transformType *text.MarkerType) {
-	count := 1
-	if countPtr != nil {
-		count = *countPtr
-		if count < 0 {
-			return
-		}
+// wordJoin returns how a counted marker groups words into units, or nil when
+// every word counts on its own.
+func wordJoin(ctx *Context, m *text.Marker) joinFunc {
+	var join joinFunc
+	if ctx.compounds {
+		join = compoundJoin
+	}
+	var own joinFunc
+	switch m.Type {
+	case text.MarkerName:
+		own = particleJoin
+	case text.MarkerDigits:
+		own = hyphenJoin
+	default:
+		return join
+	}
+	if join != nil {
+		return eitherJoin(join, own)
 	}
+	return own
+}
 
-	wordIndices := findPreviousWord(nodes, markerIndex, count)
-	for _, idx := range wordIndices {
-		nodes[idx].Value = transform(nodes[idx].Value)
-		nodes[idx].CaseTransform = transformType
+func targetDescription(m *text.Marker) string {
+	switch {
+	case m.Scope == text.ScopeBlockBegin:
+		return "words inside the region"
+	case m.Scope == text.ScopeSentence && m.Forward:
+		return "following sentence"
+	case m.Scope == text.ScopeSentence:
+		return "preceding sentence"
+	case m.Scope == text.ScopeParagraph && m.Forward:
+		return "following paragraph"
+	case m.Scope == text.ScopeParagraph:
+		return "preceding paragraph"
+	case m.Forward:
+		return "following word"
+	default:
+		return "preceding word"
 	}
 }
 
@@ -103,7 +213,8 @@ func findPreviousWord(nodes []text.Node, markerIndex int, count int) []int {
 		return nil
 	}
 
-	result := make([]int, 0, count)
+	// The count comes from the input, so it only bounds the search.
+	result := make([]int, 0, min(count, markerIndex))
 	for i := markerIndex - 1; i >= 0 && len(result) < count; i-- {
 		if nodes[i].Kind == text.NodeWord {
 			result = append(result, i)
@@ -117,11 +228,16 @@ func findPreviousWord(nodes []text.Node, markerIndex int, count int) []int {
 	return result
 }
 
-func capitalizeWord(s string) string {
-	if len(s) == 0 {
-		return s
+func findNextWord(nodes []text.Node, markerIndex int, count int) []int {
+	if count <= 0 {
+		return nil
+	}
+
+	result := make([]int, 0, min(count, len(nodes)-markerIndex))
+	for i := markerIndex + 1; i < len(nodes) && len(result) < count; i++ {
+		if nodes[i].Kind == text.NodeWord {
+			result = append(result, i)
+		}
 	}
-	runes := []rune(strings.ToLower(s))
-	runes[0] = []rune(strings.ToUpper(string(runes[0])))[0]
-	return string(runes)
+	return result
 }
@@ -1,8 +1,10 @@
 package engine
 
 import (
+	"reflect"
 	"testing"
 
+	"go-reloaded/internal/diag"
 	"go-reloaded/internal/text"
 )
 
@@ -25,6 +27,35 @@ func TestApplyMarkersNumeric(t *testing.T) {
 	checkWord(t, got[6], "2748")    // hex conversion
 }
 
+func TestApplyMarkersNumericArbitraryPrecision(t *testing.T) {
+	t.Parallel()
+
+	nodes := []text.Node{
+		word("FFFFFFFFFFFFFFFFFF"), marker(text.MarkerHex, nil),
+		word("1111111111111111111111111111111111111111111111111111111111111111111"), marker(text.MarkerBin, nil),
+		word("0x"), marker(text.MarkerHex, nil),
+	}
+	nodes[5].Marker.Offset = 42
+	nodes[5].Value = "(hex)"
+
+	res, err := ApplyMarkersWithOptions(nodes, Options{})
+	if err != nil {
+		t.Fatalf("ApplyMarkersWithOptions returned error: %v", err)
+	}
+
+	checkWord(t, res.Nodes[0], "4722366482869645213695")
+	checkWord(t, res.Nodes[2], "147573952589676412927")
+	checkWord(t, res.Nodes[4], "0x")
+
+	if len(res.Diagnostics) != 1 {
+		t.Fatalf("expected one diagnostic, got %v", res.Diagnostics)
+	}
+	d := res.Diagnostics[0]
+	if d.Code != diag.CodeInvalidNumber || d.Offset != 42 || d.Marker != "(hex)" {
+		t.Fatalf("unexpected diagnostic: %+v", d)
+	}
+}
+
 func TestApplyMarkersCaseSingle(t *testing.T) {
 	nodes := []text.Node{
 		word("hello"), marker(text.MarkerUp, nil),
@@ -75,6 +106,31 @@ func TestApplyMarkersCaseCount(t *testing.T) {
 	checkWord(t, got[12], "Bridge")
 }
 
+func TestApplyMarkersForward(t *testing.T) {
+	t.Parallel()
+
+	two := 2
+	nodes := []text.Node{
+		forward(text.MarkerUp, nil),
+		word("start"), word("here"),
+		forward(text.MarkerCap, &two),
+		word("brooklyn"), text.Node{Kind: text.NodePunct, Value: ","}, word("bridge"), word("tail"),
+		word("FF"), forward(text.MarkerHex, nil),
+	}
+
+	got, err := ApplyMarkers(nodes)
+	if err != nil {
+		t.Fatalf("ApplyMarkers returned error: %v", err)
+	}
+
+	checkWord(t, got[1], "START")
+	checkWord(t, got[2], "here")
+	checkWord(t, got[4], "Brooklyn")
+	checkWord(t, got[6], "Bridge")
+	checkWord(t, got[7], "tail")
+	checkWord(t, got[8], "FF") // nothing follows the forward marker
+}
+
 func TestApplyMarkersNegativeCount(t *testing.T) {
 	neg := -1
 	nodes := []text.Node{
@@ -92,6 +148,89 @@ func TestApplyMarkersNegativeCount(t *testing.T) {
 	checkWord(t, got[1], "calm")
 }
 
+func TestApplyMarkersReportsNoOps(t *testing.T) {
+	t.Parallel()
+
+	neg := -2
+	zero := 0
+	three := 3
+	nodes := []text.Node{
+		marker(text.MarkerCap, nil),
+		word("one"),
+		marker(text.MarkerUp, &neg),
+		marker(text.MarkerUp, &zero),
+		marker(text.MarkerLow, &three),
+		forward(text.MarkerUp, nil),
+	}
+
+	res, err := ApplyMarkersWithOptions(nodes, Options{})
+	if err != nil {
+		t.Fatalf("ApplyMarkersWithOptions returned error: %v", err)
+	}
+
+	want := []diag.Code{diag.CodeNoTarget, diag.CodeInvalidCount, diag.CodeInvalidCount, diag.CodePartial, diag.CodeNoTarget}
+	if len(res.Diagnostics) != len(want) {
+		t.Fatalf("expected %d diagnostics, got %v", len(want), res.Diagnostics)
+	}
+	for i, code := range want {
+		if res.Diagnostics[i].Code != code {
+			t.Fatalf("diagnostic %d: want %s, got %+v", i, code, res.Diagnostics[i])
+		}
+	}
+	if msg := res.Diagnostics[4].Msg; msg != "no following word to act on" {
+		t.Fatalf("unexpected message: %q", msg)
+	}
+}
+
+func TestApplyMarkersHugeCount(t *testing.T) {
+	t.Parallel()
+
+	huge := 1_000_000_000_000_000
+	nodes := []text.Node{
+		word("one"), marker(text.MarkerUp, &huge),
+		space(), forward(text.MarkerCap, &huge), word("two"),
+	}
+
+	res, err := ApplyMarkersWithOptions(nodes, Options{})
+	if err != nil {
+		t.Fatalf("ApplyMarkersWithOptions returned error: %v", err)
+	}
+
+	checkWord(t, res.Nodes[0], "ONE")
+	checkWord(t, res.Nodes[4], "Two")
+	if len(res.Diagnostics) != 2 || res.Diagnostics[0].Code != diag.CodePartial || res.Diagnostics[1].Code != diag.CodePartial {
+		t.Fatalf("expected two partial diagnostics, got %v", res.Diagnostics)
+	}
+}
+
+func TestApplyMarkersRecordsTransformHistory(t *testing.T) {
+	t.Parallel()
+
+	two, three := 2, 3
+	up := marker(text.MarkerUp, &two)
+	up.Marker.Offset = 10
+	capital := marker(text.MarkerCap, &three)
+	capital.Marker.Offset = 17
+	nodes := []text.Node{word("one"), space(), word("two"), space(), word("three"), up, capital}
+	nodes[4].Transforms = make([]text.Transform, 0, 4)
+
+	got, err := ApplyMarkers(nodes)
+	if err != nil {
+		t.Fatalf("ApplyMarkers returned error: %v", err)
+	}
+
+	want := []text.Transform{{Type: text.MarkerUp, Offset: 10}, {Type: text.MarkerCap, Offset: 17}}
+	if !reflect.DeepEqual(got[4].Transforms, want) {
+		t.Fatalf("unexpected history: %v", got[4].Transforms)
+	}
+	if want := []text.Transform{{Type: text.MarkerCap, Offset: 17}}; !reflect.DeepEqual(got[0].Transforms, want) {
+		t.Fatalf("unexpected history: %v", got[0].Transforms)
+	}
+	if len(nodes[4].Transforms) != 0 || nodes[4].Transforms[:1][0] != (text.Transform{}) {
+		t.Fatalf("input nodes were modified: %v", nodes[4].Transforms[:2])
+	}
+}
+
 func word(val string) text.Node {
 	return text.Node{Kind: text.NodeWord, Value: val}
 }
@@ -100,6 +239,12 @@ func marker(kind text.MarkerType, count *int) text.Node {
 	return text.Node{Kind: text.NodeMarker, Marker: &text.Marker{Type: kind, Count: count}}
 }
 
+func forward(kind text.MarkerType, count *int) text.Node {
+	n := marker(kind, count)
+	n.Marker.Forward = true
+	return n
+}
+
 func checkWord(t *testing.T, node text.Node, want string) {
 	t.Helper()
 	if node.Kind != text.NodeWord {
@@ -0,0 +1,125 @@
+package engine
+
+import (
+	"strings"
+	"unicode"
+
+	"go-reloaded/internal/text"
+)
+
+// identifierStyle joins the lower-case parts of an identifier.
+type identifierStyle func(c caser, parts []string) string
+
+func snakeCase(c caser, parts []string) string {
+	return strings.Join(parts, "_")
+}
+
+func kebabCase(c caser, parts []string) string {
+	return strings.Join(parts, "-")
+}
+
+func constantCase(c caser, parts []string) string {
+	return c.upper(strings.Join(parts, "_"))
+}
+
+func camelCase(c caser, parts []string) string {
+	return parts[0] + pascalCase(c, parts[1:])
+}
+
+func pascalCase(c caser, parts []string) string {
+	var b strings.Builder
+	for _, part := range parts {
+		b.WriteString(c.capitalize(part))
+	}
+	return b.String()
+}
+
+// identifierHandler handles (snake), (camel), (pascal), (kebab) and
+// (constant). The selected words, and the spaces or hyphens between them,
+// are merged into a single word node. Other punctuation splits the
+// selection, so "(snake, s)" on "big data, fast code." gives
+// "big_data, fast_code.".
+func identifierHandler(style identifierStyle) Handler {
+	return func(ctx *Context, index int, m *text.Marker) error {
+		words := targetWords(ctx, index, m)
+		// Merge from the end so the indices of earlier runs stay valid.
+		for end := len(words) - 1; end >= 0; {
+			start := end
+			for start > 0 && mergeable(ctx.Nodes, words[start-1], words[start]) {
+				start--
+			}
+
+			var parts []string
+			var history []text.Transform
+			for _, idx := range words[start : end+1] {
+				for _, part := range identifierParts(ctx.Nodes[idx].Value) {
+					parts = append(parts, ctx.caser.lower(part))
+				}
+				history = append(history, ctx.Nodes[idx].Transforms...)
+			}
+			// The merged word keeps the history of every part it absorbed.
+			merged := text.Node{
+				Kind:       text.NodeWord,
+				Value:      style(ctx.caser, parts),
+				Transforms: history,
+			}
+			markers := markerNodes(ctx.Nodes[words[start] : words[end]+1])
+			ctx.Splice(words[start], words[end]+1, append([]text.Node{merged}, markers...)...)
+			ctx.Record(words[start], m)
+			end = start - 1
+		}
+		return nil
+	}
+}
+
+// mergeable reports whether only spaces or a joining hyphen separate the
+// words at nodes[a] and nodes[b]. Markers leave no text behind, so they do
+// not separate words either.
+func mergeable(nodes []text.Node, a, b int) bool {
+	if hyphenJoin(nodes, a, b) {
+		return true
+	}
+	for i := a + 1; i < b; i++ {
+		if nodes[i].Kind != text.NodeSpace && nodes[i].Kind != text.NodeMarker {
+			return false
+		}
+	}
+	return true
+}
+
+// markerNodes returns the markers among nodes. Merging or deleting a run of
+// words keeps them, so a marker that has yet to run still applies.
+func markerNodes(nodes []text.Node) []text.Node {
+	var markers []text.Node
+	for _, node := range nodes {
+		if node.Kind == text.NodeMarker {
+			markers = append(markers, node)
+		}
+	}
+	return markers
+}
+
+// identifierParts splits a word that is already an identifier, such as
+// "user_id", "userID" or "HTTPServer", into its parts.
+func identifierParts(word string) []string {
+	var parts []string
+	for _, chunk := range strings.Split(word, "_") {
+		runes := []rune(chunk)
+		start := 0
+		for i := 1; i < len(runes); i++ {
+			if !unicode.IsUpper(runes[i]) {
+				continue
+			}
+			prev := runes[i-1]
+			acronymEnd := unicode.IsUpper(prev) && i+1 < len(runes) && unicode.IsLower(runes[i+1])
+			if unicode.IsLower(prev) || unicode.IsDigit(prev) || acronymEnd {
+				parts = append(parts, string(runes[start:i]))
+				start = i
+			}
+		}
+		if start < len(runes) {
+			parts = append(parts, string(runes[start:]))
+		}
+	}
+	return parts
+}
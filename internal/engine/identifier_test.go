@@ -0,0 +1,102 @@
+package engine
+
+import (
+	"reflect"
+	"testing"
+
+	"go-reloaded/internal/text"
+)
+
+func TestApplyMarkersIdentifierCase(t *testing.T) {
+	t.Parallel()
+
+	three := 3
+	tests := []struct {
+		kind text.MarkerType
+		want string
+	}{
+		{text.MarkerSnake, "user_account_id"},
+		{text.MarkerCamel, "userAccountId"},
+		{text.MarkerPascal, "UserAccountId"},
+		{text.MarkerKebab, "user-account-id"},
+		{text.MarkerConstant, "USER_ACCOUNT_ID"},
+	}
+
+	for _, tt := range tests {
+		nodes := []text.Node{
+			word("the"), space(), word("User"), space(), word("account"), space(), word("ID"),
+			marker(tt.kind, &three), space(), word("field"),
+		}
+
+		got, err := ApplyMarkers(nodes)
+		if err != nil {
+			t.Fatalf("%s: ApplyMarkers returned error: %v", tt.kind, err)
+		}
+		if len(got) != 6 {
+			t.Fatalf("%s: expected the words to merge into one node, got %d nodes", tt.kind, len(got))
+		}
+		checkWord(t, got[0], "the")
+		checkWord(t, got[2], tt.want)
+		checkWord(t, got[5], "field")
+		if last, ok := got[2].LastTransform(); !ok || last.Type != tt.kind {
+			t.Fatalf("%s: expected case transform to be recorded, got %v", tt.kind, got[2].Transforms)
+		}
+	}
+}
+
+func TestIdentifierCaseStopsAtPunctuation(t *testing.T) {
+	t.Parallel()
+
+	nodes := []text.Node{
+		word("big"), space(), word("data"), punct(","), space(), word("fast"), punct("-"), word("code"), punct("."), space(),
+		scoped(text.MarkerSnake, text.ScopeSentence, false),
+		space(), word("after"),
+	}
+
+	got, err := ApplyMarkers(nodes)
+	if err != nil {
+		t.Fatalf("ApplyMarkers returned error: %v", err)
+	}
+	checkWord(t, got[0], "big_data")
+	checkWord(t, got[3], "fast_code")
+	checkWord(t, got[8], "after")
+}
+
+func TestIdentifierCaseSpansAppliedMarkers(t *testing.T) {
+	t.Parallel()
+
+	three := 3
+	nodes := []text.Node{
+		word("user"), space(), marker(text.MarkerUp, nil), space(), word("account"), space(), word("id"),
+		space(), marker(text.MarkerSnake, &three),
+	}
+
+	res, err := ApplyMarkersWithOptions(nodes, Options{})
+	if err != nil {
+		t.Fatalf("ApplyMarkersWithOptions returned error: %v", err)
+	}
+	checkWord(t, res.Nodes[0], "user_account_id")
+	if res.Nodes[1].Kind != text.NodeMarker {
+		t.Fatalf("expected the applied marker to follow the merged word, got %v", res.Nodes)
+	}
+	if len(res.Diagnostics) != 0 {
+		t.Fatalf("unexpected diagnostics: %v", res.Diagnostics)
+	}
+}
+
+func TestIdentifierParts(t *testing.T) {
+	t.Parallel()
+
+	tests := map[string][]string{
+		"user":        {"user"},
+		"user_id":     {"user", "id"},
+		"userID":      {"user", "ID"},
+		"HTTPServer":  {"HTTP", "Server"},
+		"utf8Decoder": {"utf8", "Decoder"},
+	}
+	for in, want := range tests {
+		if got := identifierParts(in); !reflect.DeepEqual(got, want) {
+			t.Errorf("identifierParts(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
@@ -0,0 +1,195 @@
+package engine
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"go-reloaded/internal/diag"
+	"go-reloaded/internal/text"
+)
+
+// macroStep is one part of a user-defined marker: an existing marker applied
+// to the same words, or literal text appended after them.
+type macroStep struct {
+	marker   text.MarkerType
+	args     text.ArgShape
+	handler  Handler
+	trailing []text.Node
+}
+
+// LoadMacros registers the markers defined in r, one per line, as sequences
+// of markers that are already known. Steps are separated by "then", and
+// trailing "text" appends literal text after the selected words:
+//
+//	# house style
+//	shout = up
+//	heading = title then trailing ":"
+//
+// A macro takes the same count, scope or direction as the markers it is
+// built from, so "(heading, 3)" title-cases three words and adds a colon.
+// Blank lines and lines starting with "#" are ignored.
+func (r *Registry) LoadMacros(rd io.Reader) error {
+	scanner := bufio.NewScanner(rd)
+	for line := 1; scanner.Scan(); line++ {
+		def := strings.TrimSpace(scanner.Text())
+		if def == "" || strings.HasPrefix(def, "#") {
+			continue
+		}
+		if err := r.defineMacro(def); err != nil {
+			return fmt.Errorf("macros line %d: %w", line, err)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return fmt.Errorf("read macros: %w", err)
+	}
+	return nil
+}
+
+func (r *Registry) defineMacro(def string) error {
+	name, body, ok := strings.Cut(def, "=")
+	if !ok {
+		return fmt.Errorf("want name = marker [then ...], got %q", def)
+	}
+	name = strings.TrimSpace(name)
+
+	steps, err := r.parseMacroSteps(strings.TrimSpace(body))
+	if err != nil {
+		return fmt.Errorf("macro %q: %w", name, err)
+	}
+
+	// A macro only takes a count if something in it uses one.
+	args := text.ArgNone
+	for _, step := range steps {
+		if step.handler == nil || step.args == text.ArgCount {
+			args = text.ArgCount
+		}
+	}
+	return r.Register(text.MarkerType(name), args, macroHandler(args, steps))
+}
+
+// parseMacroSteps reads `step ("then" step)*`, where a step is a marker name
+// or trailing followed by a quoted string.
+func (r *Registry) parseMacroSteps(body string) ([]macroStep, error) {
+	var steps []macroStep
+	rest := body
+	for {
+		word, after := nextMacroWord(rest)
+		switch {
+		case word == "":
+			return nil, fmt.Errorf("missing step in %q", body)
+		case word == "trailing":
+			quoted, err := strconv.QuotedPrefix(after)
+			if err != nil {
+				return nil, fmt.Errorf("trailing wants a quoted string, got %q", after)
+			}
+			literal, _ := strconv.Unquote(quoted)
+			nodes, err := plainNodes(literal)
+			if err != nil {
+				return nil, err
+			}
+			steps = append(steps, macroStep{trailing: nodes})
+			rest = strings.TrimSpace(after[len(quoted):])
+		default:
+			spec, ok := r.syntax.Lookup(text.MarkerType(word))
+			if !ok {
+				return nil, fmt.Errorf("unknown marker %q", word)
+			}
+			if spec.Args == text.ArgString {
+				return nil, fmt.Errorf("marker %q needs an argument and cannot be used in a macro", word)
+			}
+			steps = append(steps, macroStep{marker: spec.Type, args: spec.Args, handler: r.handlers[spec.Type]})
+			rest = after
+		}
+
+		if rest == "" {
+			return steps, nil
+		}
+		then, after := nextMacroWord(rest)
+		if then != "then" {
+			return nil, fmt.Errorf("want then between steps, got %q", rest)
+		}
+		rest = after
+	}
+}
+
+// nextMacroWord splits the first space-separated word off s.
+func nextMacroWord(s string) (string, string) {
+	s = strings.TrimSpace(s)
+	word, rest, _ := strings.Cut(s, " ")
+	return word, strings.TrimSpace(rest)
+}
+
+// macroHandler applies each step in turn to the words the macro selects.
+// Counted macros select their words, and report a missing or short target,
+// once up front: after a step such as snake has merged three words into one,
+// a later step asking for three words again would otherwise find too few.
+func macroHandler(args text.ArgShape, steps []macroStep) Handler {
+	return func(ctx *Context, index int, m *text.Marker) error {
+		var anchor func() int
+		if args == text.ArgCount {
+			words := targetWords(ctx, index, m)
+			if len(words) == 0 {
+				return nil
+			}
+			anchor = trailingAnchor(ctx, index, words)
+		}
+
+		for _, step := range steps {
+			if step.handler == nil {
+				at := anchor()
+				ctx.Splice(at, at, step.trailing...)
+				continue
+			}
+
+			stepMarker := *m
+			stepMarker.Type = step.marker
+			if step.args == text.ArgNone {
+				stepMarker.Count, stepMarker.Scope = nil, text.ScopeWords
+			}
+			if end, ok := ctx.blockEnds[m]; ok {
+				ctx.blockEnds[&stepMarker] = end
+			}
+			reported := len(ctx.Diagnostics)
+			// Earlier steps may have added or removed nodes before the marker.
+			if err := step.handler(ctx, ctx.cursor, &stepMarker); err != nil {
+				return err
+			}
+			if step.args == text.ArgCount {
+				dropTargetDiagnostics(ctx, reported)
+			}
+		}
+		return nil
+	}
+}
+
+// trailingAnchor returns where trailing text goes: just after the selected
+// words, however earlier steps have since reshaped them. Steps only edit the
+// selection itself, so the distance from the end of the selection to the
+// marker (for words before it) or to the end of the input (for words after
+// it) does not change.
+func trailingAnchor(ctx *Context, index int, words []int) func() int {
+	end := words[len(words)-1] + 1
+	if words[0] > index {
+		tail := len(ctx.Nodes) - end
+		return func() int { return len(ctx.Nodes) - tail }
+	}
+	gap := index - end
+	return func() int { return ctx.cursor - gap }
+}
+
+// dropTargetDiagnostics discards the target findings a step reported since
+// from; the macro has already reported its own.
+func dropTargetDiagnostics(ctx *Context, from int) {
+	kept := ctx.Diagnostics[:from]
+	for _, d := range ctx.Diagnostics[from:] {
+		switch d.Code {
+		case diag.CodeNoTarget, diag.CodePartial, diag.CodeInvalidCount:
+		default:
+			kept = append(kept, d)
+		}
+	}
+	ctx.Diagnostics = kept
+}
@@ -0,0 +1,152 @@
+package engine
+
+import (
+	"strings"
+	"testing"
+
+	"go-reloaded/internal/diag"
+	"go-reloaded/internal/text"
+)
+
+func TestLoadMacros(t *testing.T) {
+	t.Parallel()
+
+	r := NewRegistry()
+	config := "# house style\n\nshout = up\nheading = title then trailing \":\"\nfix = swap\n"
+	if err := r.LoadMacros(strings.NewReader(config)); err != nil {
+		t.Fatalf("LoadMacros returned error: %v", err)
+	}
+
+	want := map[text.MarkerType]text.ArgShape{"shout": text.ArgCount, "heading": text.ArgCount, "fix": text.ArgNone}
+	for name, args := range want {
+		spec, ok := r.Syntax().Lookup(name)
+		if !ok || spec.Args != args {
+			t.Fatalf("expected %q with %s arguments, got %+v (found %v)", name, args, spec, ok)
+		}
+	}
+	if _, ok := NewRegistry().Syntax().Lookup("shout"); ok {
+		t.Fatal("macros must not leak into other registries")
+	}
+}
+
+func TestLoadMacrosErrors(t *testing.T) {
+	t.Parallel()
+
+	tests := map[string]string{
+		"shout":              `macros line 1: want name = marker [then ...], got "shout"`,
+		"shout = nope":       `macros line 1: macro "shout": unknown marker "nope"`,
+		"shout = var":        `macros line 1: macro "shout": marker "var" needs an argument and cannot be used in a macro`,
+		"shout = up and cap": `macros line 1: macro "shout": want then between steps, got "and cap"`,
+		"shout = up then":    `macros line 1: macro "shout": missing step in "up then"`,
+		"end = trailing :":   `macros line 1: macro "end": trailing wants a quoted string, got ":"`,
+		"# ok\nup = cap":     `macros line 2: marker "up" already registered`,
+		"Shout = up":         `macros line 1: invalid marker name "Shout"`,
+	}
+	for config, want := range tests {
+		err := NewRegistry().LoadMacros(strings.NewReader(config))
+		if err == nil || err.Error() != want {
+			t.Errorf("LoadMacros(%q) error = %v, want %q", config, err, want)
+		}
+	}
+}
+
+func TestApplyMarkersMacros(t *testing.T) {
+	t.Parallel()
+
+	r := NewRegistry()
+	if err := r.LoadMacros(strings.NewReader("heading = title then trailing \":\"\nloud = heading then up")); err != nil {
+		t.Fatalf("LoadMacros returned error: %v", err)
+	}
+
+	three := 3
+	nodes := []text.Node{
+		word("the"), space(), word("art"), space(), word("of"), space(), word("war"),
+		marker("heading", &three),
+		space(), word("go"), marker("loud", nil),
+	}
+
+	res, err := ApplyMarkersWithOptions(nodes, Options{Registry: r})
+	if err != nil {
+		t.Fatalf("ApplyMarkersWithOptions returned error: %v", err)
+	}
+	got := res.Nodes
+
+	checkWord(t, got[2], "Art")
+	checkWord(t, got[4], "of")
+	checkWord(t, got[6], "War")
+	if got[7].Value != ":" {
+		t.Fatalf("expected a trailing colon, got %q", got[7].Value)
+	}
+	checkWord(t, got[10], "GO")
+	if got[11].Value != ":" {
+		t.Fatalf("expected a trailing colon after a nested macro, got %q", got[11].Value)
+	}
+	if len(res.Diagnostics) != 0 {
+		t.Fatalf("unexpected diagnostics: %v", res.Diagnostics)
+	}
+}
+
+func TestMacroReportsOnce(t *testing.T) {
+	t.Parallel()
+
+	r := NewRegistry()
+	if err := r.LoadMacros(strings.NewReader(`heading = title then up then trailing ":"`)); err != nil {
+		t.Fatalf("LoadMacros returned error: %v", err)
+	}
+
+	res, err := ApplyMarkersWithOptions([]text.Node{marker("heading", nil)}, Options{Registry: r})
+	if err != nil {
+		t.Fatalf("ApplyMarkersWithOptions returned error: %v", err)
+	}
+	if len(res.Diagnostics) != 1 || res.Diagnostics[0].Code != diag.CodeNoTarget {
+		t.Fatalf("expected a single no-target diagnostic, got %v", res.Diagnostics)
+	}
+}
+
+func TestMacroStepsAfterMerge(t *testing.T) {
+	t.Parallel()
+
+	r := NewRegistry()
+	if err := r.LoadMacros(strings.NewReader(`id = snake then trailing ":"`)); err != nil {
+		t.Fatalf("LoadMacros returned error: %v", err)
+	}
+
+	three := 3
+	nodes := []text.Node{
+		word("user"), space(), word("account"), space(), word("id"), marker("id", &three),
+	}
+	res, err := ApplyMarkersWithOptions(nodes, Options{Registry: r})
+	if err != nil {
+		t.Fatalf("ApplyMarkersWithOptions returned error: %v", err)
+	}
+	checkWord(t, res.Nodes[0], "user_account_id")
+	if res.Nodes[1].Value != ":" {
+		t.Fatalf("expected a trailing colon after the merged word, got %q", res.Nodes[1].Value)
+	}
+	if len(res.Diagnostics) != 0 {
+		t.Fatalf("unexpected diagnostics: %v", res.Diagnostics)
+	}
+}
+
+func TestMacroAppliesToBlocks(t *testing.T) {
+	t.Parallel()
+
+	r := NewRegistry()
+	if err := r.LoadMacros(strings.NewReader("shout = up")); err != nil {
+		t.Fatalf("LoadMacros returned error: %v", err)
+	}
+
+	nodes := []text.Node{
+		block("shout", text.ScopeBlockBegin), word("in"), space(), word("here"), block("shout", text.ScopeBlockEnd), space(), word("out"),
+	}
+	res, err := ApplyMarkersWithOptions(nodes, Options{Registry: r})
+	if err != nil {
+		t.Fatalf("ApplyMarkersWithOptions returned error: %v", err)
+	}
+	checkWord(t, res.Nodes[1], "IN")
+	checkWord(t, res.Nodes[3], "HERE")
+	checkWord(t, res.Nodes[6], "out")
+	if len(res.Diagnostics) != 0 {
+		t.Fatalf("unexpected diagnostics: %v", res.Diagnostics)
+	}
+}
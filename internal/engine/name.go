@@ -0,0 +1,70 @@
+package engine
+
+import (
+	"strings"
+
+	"go-reloaded/internal/text"
+)
+
+// nameParticles are the surname prefixes that stay lower-case inside a name,
+// as in "Ludwig van Beethoven" or "Leonardo da Vinci".
+var nameParticles = map[string]bool{
+	"van": true, "von": true, "der": true, "den": true, "dem": true, "ter": true, "ten": true,
+	"de": true, "del": true, "della": true, "des": true, "di": true, "da": true, "du": true,
+	"la": true, "le": true, "zu": true, "bin": true, "ibn": true,
+}
+
+// macExceptions are ordinary names that start with "mac" without being a
+// Mac prefix.
+var macExceptions = map[string]bool{
+	"mace": true, "macey": true, "machado": true, "machin": true, "macias": true,
+	"mack": true, "mackie": true, "macon": true, "macy": true,
+}
+
+// particleJoin makes a run of particles such as "van der" count as one part
+// of a name, so "van der berg (name, 2)" covers the whole surname.
+func particleJoin(nodes []text.Node, a, b int) bool {
+	if !isParticle(nodes[a].Value) || !isParticle(nodes[b].Value) {
+		return false
+	}
+	for i := a + 1; i < b; i++ {
+		if nodes[i].Kind != text.NodeSpace {
+			return false
+		}
+	}
+	return true
+}
+
+func isParticle(word string) bool {
+	return nameParticles[strings.ToLower(word)]
+}
+
+// applyNameCase handles (name) and (name, n). Each part of the name is
+// capitalised, including after an O', Mc or Mac prefix, while particles stay
+// lower-case unless they end the selection.
+func applyNameCase(ctx *Context, index int, m *text.Marker) error {
+	wordIndices := targetWords(ctx, index, m)
+	last := len(wordIndices) - 1
+	for pos, idx := range wordIndices {
+		word := ctx.Nodes[idx].Value
+		ctx.Nodes[idx].Value = ctx.protected.protect(word, nameWord(ctx.caser, word, pos == last))
+		ctx.Record(idx, m)
+	}
+	return nil
+}
+
+func nameWord(c caser, word string, last bool) string {
+	lower := c.lower(word)
+	switch {
+	case !last && nameParticles[lower]:
+		return lower
+	case strings.HasPrefix(lower, "o'") && len(lower) > 2, strings.HasPrefix(lower, "d'") && len(lower) > 2:
+		return c.upper(lower[:1]) + "'" + c.capitalize(lower[2:])
+	case strings.HasPrefix(lower, "mc") && len(lower) > 2:
+		return "Mc" + c.capitalize(lower[2:])
+	case strings.HasPrefix(lower, "mac") && len(lower) > 5 && !macExceptions[lower]:
+		return "Mac" + c.capitalize(lower[3:])
+	default:
+		return c.capitalize(word)
+	}
+}
@@ -0,0 +1,68 @@
+package engine
+
+import (
+	"testing"
+
+	"go-reloaded/internal/text"
+)
+
+func TestApplyMarkersName(t *testing.T) {
+	t.Parallel()
+
+	four := 4
+	nodes := []text.Node{
+		word("o'neil"), space(), word("MCDONALD"), space(), word("van"), space(), word("der"), space(), word("berg"),
+		marker(text.MarkerName, &four),
+	}
+
+	res, err := ApplyMarkersWithOptions(nodes, Options{})
+	if err != nil {
+		t.Fatalf("ApplyMarkersWithOptions returned error: %v", err)
+	}
+	got := res.Nodes
+
+	checkWord(t, got[0], "O'Neil")
+	checkWord(t, got[2], "McDonald")
+	checkWord(t, got[4], "van")
+	checkWord(t, got[6], "der")
+	checkWord(t, got[8], "Berg")
+	if len(res.Diagnostics) != 0 {
+		t.Fatalf("unexpected diagnostics: %v", res.Diagnostics)
+	}
+}
+
+func TestNameWord(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		in   string
+		last bool
+		want string
+	}{
+		{"macdonald", false, "MacDonald"},
+		{"mack", false, "Mack"},
+		{"macy", false, "Macy"},
+		{"mcarthur", false, "McArthur"},
+		{"d'angelo", false, "D'Angelo"},
+		{"DA", false, "da"},
+		{"van", true, "Van"},
+		{"smith's", true, "Smith's"},
+	}
+
+	for _, tt := range tests {
+		if got := nameWord(caser{}, tt.in, tt.last); got != tt.want {
+			t.Errorf("nameWord(%q, %v) = %q, want %q", tt.in, tt.last, got, tt.want)
+		}
+	}
+}
+
+func TestParticlesCountAsOnePart(t *testing.T) {
+	t.Parallel()
+
+	nodes := []text.Node{word("ludwig"), space(), word("van"), space(), word("der"), punct(","), space(), word("de")}
+
+	words, found := findPreviousUnits(nodes, len(nodes), 2, particleJoin)
+	if found != 2 || len(words) != 3 || words[0] != 2 {
+		t.Fatalf("unexpected selection %v (%d parts)", words, found)
+	}
+}
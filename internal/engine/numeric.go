@@ -0,0 +1,194 @@
+package engine
+
+import (
+	"math/big"
+	"strings"
+
+	"go-reloaded/internal/diag"
+	"go-reloaded/internal/text"
+)
+
+// numberConversion describes a numeric marker: how the previous word is read
+// and how the resulting value is written back.
+type numberConversion struct {
+	from   string // name of the accepted input notation, used in diagnostics
+	parse  func(string) (*big.Int, bool)
+	format func(*big.Int) (string, bool)
+	to     string // name of the output notation, used in diagnostics
+	// spelled conversions read hyphen-joined words such as "forty-two" as one
+	// number and may write several words back.
+	spelled bool
+}
+
+var (
+	hexToDecimal     = numberConversion{from: "hexadecimal", parse: baseParser(16), format: baseFormatter(10), to: "decimal"}
+	binToDecimal     = numberConversion{from: "binary", parse: baseParser(2), format: baseFormatter(10), to: "decimal"}
+	octToDecimal     = numberConversion{from: "octal", parse: baseParser(8), format: baseFormatter(10), to: "decimal"}
+	decimalToHex     = numberConversion{from: "decimal", parse: baseParser(10), format: baseFormatter(16), to: "hexadecimal"}
+	decimalToBin     = numberConversion{from: "decimal", parse: baseParser(10), format: baseFormatter(2), to: "binary"}
+	romanToDecimal   = numberConversion{from: "Roman", parse: parseRoman, format: baseFormatter(10), to: "decimal"}
+	decimalToRoman   = numberConversion{from: "decimal", parse: baseParser(10), format: formatRoman, to: "a Roman numeral"}
+	decimalToWords   = numberConversion{from: "decimal", parse: baseParser(10), format: formatWords, to: "words", spelled: true}
+	wordsToDecimal   = numberConversion{from: "spelled-out", parse: parseWords, format: baseFormatter(10), to: "decimal", spelled: true}
+	decimalToOrdinal = numberConversion{from: "decimal", parse: baseParser(10), format: formatOrdinal, to: "an ordinal"}
+)
+
+// numberPrefixes lists the literal prefixes accepted for each base.
+var numberPrefixes = map[int][]string{
+	2:  {"0b", "0B"},
+	8:  {"0o", "0O"},
+	16: {"0x", "0X"},
+}
+
+func numericHandler(conv numberConversion) Handler {
+	return func(ctx *Context, index int, m *text.Marker) error {
+		return applyNumericConversion(ctx, index, m, conv)
+	}
+}
+
+// applyNumericConversion rewrites the marker's target words according to
+// conv. Values of any length are supported, as are the usual base prefix, "_"
+// digit separators and a leading sign. Words that cannot be converted are left
+// unchanged and reported individually.
+func applyNumericConversion(ctx *Context, markerIndex int, m *text.Marker, conv numberConversion) error {
+	wordIndices := targetWords(ctx, markerIndex, m)
+	shift := 0
+	for _, span := range numberSpans(ctx.Nodes, wordIndices, conv) {
+		// Each rewrite so far changes the node count, shifting the later
+		// words, and the marker too when the words precede it. Region and
+		// forward markers come before their words and stay put.
+		at := markerIndex
+		if wordIndices[0] < markerIndex {
+			at += shift
+		}
+		shift += convertWord(ctx, at, span[0]+shift, span[1]+shift, m, conv)
+	}
+	return nil
+}
+
+// numberSpans groups the selected words into the [first, last] node ranges
+// that each hold one number. Only spelled conversions join words.
+func numberSpans(nodes []text.Node, wordIndices []int, conv numberConversion) [][2]int {
+	var join joinFunc
+	if conv.spelled {
+		join = hyphenJoin
+	}
+	return unitSpans(nodes, wordIndices, join)
+}
+
+// convertWord converts the number written in ctx.Nodes[first:last+1] and
+// returns the resulting change in the number of nodes.
+func convertWord(ctx *Context, markerIndex, first, last int, m *text.Marker, conv numberConversion) int {
+	var b strings.Builder
+	for _, node := range ctx.Nodes[first : last+1] {
+		b.WriteString(node.Value)
+	}
+	num := b.String()
+
+	parsed, ok := conv.parse(num)
+	if !ok {
+		ctx.Report(markerIndex, diag.CodeInvalidNumber, "%q is not a valid %s number", num, conv.from)
+		return 0
+	}
+
+	signIdx, negative, signed := signBefore(ctx.Nodes, first)
+	if negative {
+		parsed.Neg(parsed)
+	}
+
+	formatted, ok := conv.format(parsed)
+	if !ok {
+		ctx.Report(markerIndex, diag.CodeOutOfRange, "%s cannot be written as %s", parsed, conv.to)
+		return 0
+	}
+
+	var history []text.Transform
+	for _, node := range ctx.Nodes[first : last+1] {
+		history = append(history, node.Transforms...)
+	}
+	repl := []text.Node{{Kind: text.NodeWord, Value: formatted}}
+	if conv.spelled {
+		repl = spelledNodes(formatted)
+	}
+	start := first
+	if signed {
+		start = signIdx
+	}
+	ctx.Splice(start, last+1, repl...)
+	for i, node := range repl {
+		if node.Kind == text.NodeWord {
+			ctx.Nodes[start+i].Transforms = history
+			ctx.Record(start+i, m)
+		}
+	}
+	return len(repl) - (last + 1 - start)
+}
+
+// spelledNodes splits spelled-out output such as "minus forty-two" into the
+// word, space and hyphen nodes the parser would produce for it.
+func spelledNodes(s string) []text.Node {
+	var nodes []text.Node
+	for i, field := range strings.Split(s, " ") {
+		if i > 0 {
+			nodes = append(nodes, text.Node{Kind: text.NodeSpace, Value: " "})
+		}
+		for j, part := range strings.Split(field, "-") {
+			if j > 0 {
+				nodes = append(nodes, text.Node{Kind: text.NodePunct, Value: "-"})
+			}
+			nodes = append(nodes, text.Node{Kind: text.NodeWord, Value: part})
+		}
+	}
+	return nodes
+}
+
+func baseParser(base int) func(string) (*big.Int, bool) {
+	return func(literal string) (*big.Int, bool) {
+		return parseInteger(literal, base)
+	}
+}
+
+func baseFormatter(base int) func(*big.Int) (string, bool) {
+	return func(n *big.Int) (string, bool) {
+		return strings.ToUpper(n.Text(base)), true
+	}
+}
+
+// parseInteger parses an unsigned literal in base, accepting an optional base
+// prefix and single "_" separators between digits.
+func parseInteger(literal string, base int) (*big.Int, bool) {
+	for _, prefix := range numberPrefixes[base] {
+		if strings.HasPrefix(literal, prefix) {
+			literal = literal[len(prefix):]
+			break
+		}
+	}
+	if literal == "" || strings.HasPrefix(literal, "_") || strings.HasSuffix(literal, "_") || strings.Contains(literal, "__") {
+		return nil, false
+	}
+	digits := strings.ReplaceAll(literal, "_", "")
+	if strings.ContainsAny(digits, "+-") {
+		return nil, false
+	}
+	return new(big.Int).SetString(digits, base)
+}
+
+// signBefore reports whether the word at idx is directly preceded by a "-" or
+// "+" that acts as a sign rather than joining two words, as in "state-of".
+func signBefore(nodes []text.Node, idx int) (int, bool, bool) {
+	signIdx := idx - 1
+	if signIdx < 0 || nodes[signIdx].Kind != text.NodePunct {
+		return 0, false, false
+	}
+	sign := nodes[signIdx].Value
+	if sign != "-" && sign != "+" {
+		return 0, false, false
+	}
+	if signIdx > 0 {
+		switch nodes[signIdx-1].Kind {
+		case text.NodeWord, text.NodeApostrophe:
+			return 0, false, false
+		}
+	}
+	return signIdx, sign == "-", true
+}
@@ -0,0 +1,176 @@
+package engine
+
+import (
+	"testing"
+
+	"go-reloaded/internal/diag"
+	"go-reloaded/internal/text"
+)
+
+func TestParseInteger(t *testing.T) {
+	t.Parallel()
+
+	cases := []struct {
+		literal string
+		base    int
+		want    string
+		ok      bool
+	}{
+		{"1F", 16, "31", true},
+		{"0x1F", 16, "31", true},
+		{"0XfF", 16, "255", true},
+		{"0b1010", 2, "10", true},
+		{"1111_0000", 2, "240", true},
+		{"dead_beef", 16, "3735928559", true},
+		{"0x", 16, "", false},
+		{"_1", 2, "", false},
+		{"1_", 2, "", false},
+		{"1__0", 2, "", false},
+		{"0b102", 2, "", false},
+		{"0x1F", 2, "", false},
+	}
+
+	for _, tc := range cases {
+		got, ok := parseInteger(tc.literal, tc.base)
+		if ok != tc.ok {
+			t.Fatalf("parseInteger(%q, %d) ok = %v, want %v", tc.literal, tc.base, ok, tc.ok)
+		}
+		if ok && got.String() != tc.want {
+			t.Fatalf("parseInteger(%q, %d) = %s, want %s", tc.literal, tc.base, got, tc.want)
+		}
+	}
+}
+
+func TestApplyMarkersSignedNumbers(t *testing.T) {
+	t.Parallel()
+
+	nodes := []text.Node{
+		punct("-"), word("1A"), marker(text.MarkerHex, nil),
+		space(), punct("+"), word("0b11"), marker(text.MarkerBin, nil),
+		space(), word("x"), punct("-"), word("10"), marker(text.MarkerBin, nil),
+	}
+
+	got, err := ApplyMarkers(nodes)
+	if err != nil {
+		t.Fatalf("ApplyMarkers returned error: %v", err)
+	}
+
+	if len(got) != len(nodes)-2 {
+		t.Fatalf("expected sign nodes to be removed, got %d nodes", len(got))
+	}
+	checkWord(t, got[0], "-26")
+	checkWord(t, got[3], "3")
+	// A hyphen between words is not a sign.
+	checkWord(t, got[6], "x")
+	checkWord(t, got[8], "2")
+}
+
+func punct(val string) text.Node {
+	return text.Node{Kind: text.NodePunct, Value: val}
+}
+
+func space() text.Node {
+	return text.Node{Kind: text.NodeSpace, Value: " "}
+}
+
+func TestApplyMarkersNumericNotations(t *testing.T) {
+	t.Parallel()
+
+	cases := []struct {
+		marker text.MarkerType
+		input  string
+		want   string
+	}{
+		{text.MarkerOct, "17", "15"},
+		{text.MarkerOct, "0o755", "493"},
+		{text.MarkerOct, "8", "8"},
+		{text.MarkerToHex, "255", "FF"},
+		{text.MarkerToHex, "1_000_000", "F4240"},
+		{text.MarkerToHex, "0xFF", "0xFF"},
+		{text.MarkerToBin, "10", "1010"},
+		{text.MarkerToBin, "0", "0"},
+		{text.MarkerRoman, "XIV", "14"},
+		{text.MarkerRoman, "mcmxcix", "1999"},
+		{text.MarkerRoman, "IIII", "IIII"},
+		{text.MarkerRoman, "VX", "VX"},
+		{text.MarkerToRoman, "2024", "MMXXIV"},
+		{text.MarkerToRoman, "0", "0"},
+		{text.MarkerToRoman, "4000", "4000"},
+	}
+
+	for _, tc := range cases {
+		got, err := ApplyMarkers([]text.Node{word(tc.input), marker(tc.marker, nil)})
+		if err != nil {
+			t.Fatalf("%s: ApplyMarkers returned error: %v", tc.marker, err)
+		}
+		if got[0].Value != tc.want {
+			t.Fatalf("%q (%s) = %q, want %q", tc.input, tc.marker, got[0].Value, tc.want)
+		}
+	}
+}
+
+func TestApplyMarkersNumericDiagnostics(t *testing.T) {
+	t.Parallel()
+
+	nodes := []text.Node{
+		word("IIII"), marker(text.MarkerRoman, nil),
+		word("0"), marker(text.MarkerToRoman, nil),
+		punct("-"), word("12"), marker(text.MarkerToHex, nil),
+	}
+
+	res, err := ApplyMarkersWithOptions(nodes, Options{})
+	if err != nil {
+		t.Fatalf("ApplyMarkersWithOptions returned error: %v", err)
+	}
+
+	checkWord(t, res.Nodes[4], "-C")
+	if len(res.Diagnostics) != 2 {
+		t.Fatalf("expected two diagnostics, got %v", res.Diagnostics)
+	}
+	if res.Diagnostics[0].Code != diag.CodeInvalidNumber {
+		t.Fatalf("unexpected first diagnostic: %+v", res.Diagnostics[0])
+	}
+	if res.Diagnostics[1].Code != diag.CodeOutOfRange {
+		t.Fatalf("unexpected second diagnostic: %+v", res.Diagnostics[1])
+	}
+}
+
+func TestApplyMarkersNumericCount(t *testing.T) {
+	t.Parallel()
+
+	three := 3
+	two := 2
+	nodes := []text.Node{
+		word("FF"), space(), word("10"), space(), word("1A"), marker(text.MarkerHex, &three),
+		space(), word("row"), space(), punct("-"), word("11"), space(), word("zz"), space(), word("101"),
+		marker(text.MarkerBin, &three),
+		space(), word("7"), space(), word("9"), marker(text.MarkerOct, &two),
+	}
+
+	res, err := ApplyMarkersWithOptions(nodes, Options{})
+	if err != nil {
+		t.Fatalf("ApplyMarkersWithOptions returned error: %v", err)
+	}
+
+	checkWord(t, res.Nodes[0], "255")
+	checkWord(t, res.Nodes[2], "16")
+	checkWord(t, res.Nodes[4], "26")
+	checkWord(t, res.Nodes[7], "row")
+	checkWord(t, res.Nodes[9], "-3")
+	checkWord(t, res.Nodes[11], "zz")
+	checkWord(t, res.Nodes[13], "5")
+	checkWord(t, res.Nodes[16], "7")
+	checkWord(t, res.Nodes[18], "9")
+
+	if len(res.Diagnostics) != 2 {
+		t.Fatalf("expected two diagnostics, got %v", res.Diagnostics)
+	}
+	for _, d := range res.Diagnostics {
+		if d.Code != diag.CodeInvalidNumber {
+			t.Fatalf("unexpected diagnostic: %+v", d)
+		}
+	}
+	if want := `"zz" is not a valid binary number`; res.Diagnostics[0].Msg != want {
+		t.Fatalf("unexpected message: %q", res.Diagnostics[0].Msg)
+	}
+}
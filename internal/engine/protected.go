@@ -0,0 +1,79 @@
+package engine
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strings"
+	"unicode"
+)
+
+// ProtectedWords maps the lower-case form of a word to the spelling that
+// case markers must keep, e.g. "nasa" to "NASA" and "iphone" to "iPhone".
+type ProtectedWords map[string]string
+
+// builtinProtected lists acronyms and brand names whose casing is fixed.
+// Words that are also ordinary English, such as "US" or "IT", are left out.
+var builtinProtected = []string{
+	"NASA", "NATO", "UNESCO", "UNICEF", "FIFA", "NBA", "FBI", "CIA",
+	"USA", "UK", "EU", "BBC", "CNN", "DNA", "RNA", "HIV",
+	"HTML", "CSS", "JSON", "XML", "HTTP", "HTTPS", "URL", "API",
+	"CPU", "GPU", "PDF", "SQL", "USB",
+	"iPhone", "iPad", "iPod", "iOS", "iCloud", "iTunes", "macOS", "eBay",
+	"PayPal", "YouTube", "GitHub", "LinkedIn", "JavaScript", "TypeScript",
+	"PostgreSQL", "MySQL",
+}
+
+// defaultProtected backs Options.Protected when it is nil. It is never
+// mutated after init.
+var defaultProtected = DefaultProtectedWords()
+
+// DefaultProtectedWords returns a fresh dictionary of the built-in acronyms
+// and brand spellings.
+func DefaultProtectedWords() ProtectedWords {
+	p := make(ProtectedWords, len(builtinProtected))
+	for _, spelling := range builtinProtected {
+		p.Add(spelling)
+	}
+	return p
+}
+
+// Add protects spelling, replacing any earlier entry for the same word.
+func (p ProtectedWords) Add(spelling string) {
+	p[strings.ToLower(spelling)] = spelling
+}
+
+// Load adds the words listed in r, one per line. Blank lines and lines
+// starting with "#" are ignored.
+func (p ProtectedWords) Load(r io.Reader) error {
+	scanner := bufio.NewScanner(r)
+	for line := 1; scanner.Scan(); line++ {
+		spelling := strings.TrimSpace(scanner.Text())
+		if spelling == "" || strings.HasPrefix(spelling, "#") {
+			continue
+		}
+		if strings.IndexFunc(spelling, unicode.IsSpace) >= 0 {
+			return fmt.Errorf("protected words line %d: %q is not a single word", line, spelling)
+		}
+		p.Add(spelling)
+	}
+	if err := scanner.Err(); err != nil {
+		return fmt.Errorf("read protected words: %w", err)
+	}
+	return nil
+}
+
+// protect restores the protected spelling of word after a case transform
+// produced cased. A possessive or contracted ending is kept as transformed,
+// so "nasa's" capitalised becomes "NASA's".
+func (p ProtectedWords) protect(word, cased string) string {
+	stem, _, _ := strings.Cut(word, "'")
+	spelling, ok := p[strings.ToLower(stem)]
+	if !ok {
+		return cased
+	}
+	if i := strings.IndexByte(cased, '\''); i >= 0 {
+		return spelling + cased[i:]
+	}
+	return spelling
+}
@@ -0,0 +1,63 @@
+package engine
+
+import (
+	"strings"
+	"testing"
+
+	"go-reloaded/internal/text"
+)
+
+func TestApplyMarkersProtectedWords(t *testing.T) {
+	t.Parallel()
+
+	three, two := 3, 2
+	nodes := []text.Node{
+		word("nasa"), space(), word("launched"), space(), word("iphone"), marker(text.MarkerCap, &three),
+		space(), word("NATO"), space(), word("iOS"), marker(text.MarkerLow, &two),
+		space(), word("nasa's"), marker(text.MarkerCap, nil),
+		space(), word("the"), space(), word("ios"), space(), word("guide"), marker(text.MarkerTitle, &three),
+	}
+
+	got, err := ApplyMarkers(nodes)
+	if err != nil {
+		t.Fatalf("ApplyMarkers returned error: %v", err)
+	}
+
+	checkWord(t, got[0], "NASA")
+	checkWord(t, got[2], "Launched")
+	checkWord(t, got[4], "iPhone")
+	checkWord(t, got[7], "NATO")
+	checkWord(t, got[9], "iOS")
+	checkWord(t, got[12], "NASA's")
+	checkWord(t, got[15], "The")
+	checkWord(t, got[17], "iOS")
+	checkWord(t, got[19], "Guide")
+}
+
+func TestApplyMarkersEmptyProtectedWords(t *testing.T) {
+	t.Parallel()
+
+	nodes := []text.Node{word("nasa"), marker(text.MarkerCap, nil)}
+	res, err := ApplyMarkersWithOptions(nodes, Options{Protected: ProtectedWords{}})
+	if err != nil {
+		t.Fatalf("ApplyMarkersWithOptions returned error: %v", err)
+	}
+	checkWord(t, res.Nodes[0], "Nasa")
+}
+
+func TestProtectedWordsLoad(t *testing.T) {
+	t.Parallel()
+
+	p := ProtectedWords{}
+	if err := p.Load(strings.NewReader("# comment\n\n  AcmeCorp  \nnasa\n")); err != nil {
+		t.Fatalf("Load returned error: %v", err)
+	}
+	if p["acmecorp"] != "AcmeCorp" || p["nasa"] != "nasa" || len(p) != 2 {
+		t.Fatalf("unexpected dictionary: %v", p)
+	}
+
+	err := p.Load(strings.NewReader("ok\ntwo words\n"))
+	if err == nil || !strings.Contains(err.Error(), "line 2") {
+		t.Fatalf("expected a line 2 error, got %v", err)
+	}
+}
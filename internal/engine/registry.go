@@ -0,0 +1,165 @@
+package engine
+
+import (
+	"fmt"
+
+	"go-reloaded/internal/diag"
+	"go-reloaded/internal/text"
+)
+
+// Context is the mutable state shared by marker handlers during a single
+// ApplyMarkers call.
+type Context struct {
+	// Nodes is the working copy of the node list. Handlers rewrite it in place.
+	Nodes []text.Node
+	// Diagnostics collects findings about markers that could not be applied.
+	Diagnostics []diag.Diagnostic
+
+	cursor    int                           // index of the marker being applied
+	blockEnds map[*text.Marker]*text.Marker // (begin x) markers to their (end x)
+	caser     caser                         // case mappings for Options.Locale
+	protected ProtectedWords                // see Options.Protected
+	vars      map[string]string             // see Options.Vars
+	compounds bool                          // see Options.Compounds
+}
+
+// Splice replaces ctx.Nodes[start:end] with repl. Handlers must use it rather
+// than reslicing Nodes themselves so the engine keeps its place in the list.
+// Indices at or after end shift by the change in length.
+func (ctx *Context) Splice(start, end int, repl ...text.Node) {
+	delta := len(repl) - (end - start)
+	nodes := make([]text.Node, 0, len(ctx.Nodes)+delta)
+	nodes = append(nodes, ctx.Nodes[:start]...)
+	nodes = append(nodes, repl...)
+	nodes = append(nodes, ctx.Nodes[end:]...)
+	ctx.Nodes = nodes
+
+	switch {
+	case ctx.cursor >= end:
+		ctx.cursor += delta
+	case ctx.cursor >= start:
+		// The current marker was replaced; resume after the replacement.
+		ctx.cursor = start + len(repl) - 1
+	}
+}
+
+// Report records a diagnostic against the marker at ctx.Nodes[index].
+func (ctx *Context) Report(index int, code diag.Code, format string, args ...any) {
+	node := ctx.Nodes[index]
+	d := diag.Diagnostic{
+		Marker: node.Value,
+		Code:   code,
+		Msg:    fmt.Sprintf(format, args...),
+	}
+	if node.Marker != nil {
+		d.Offset = node.Marker.Offset
+	}
+	ctx.Diagnostics = append(ctx.Diagnostics, d)
+}
+
+// Targets returns the indices of the words the marker at ctx.Nodes[index]
+// acts on, honouring its count, direction, sentence or paragraph scope and
+// (begin x) region. A marker that selects nothing, or fewer words than it
+// asks for, is reported.
+func (ctx *Context) Targets(index int, m *text.Marker) []int {
+	return targetWords(ctx, index, m)
+}
+
+// Record appends m to the transform history of the word at ctx.Nodes[index].
+// Handlers call it for every word they rewrite.
+func (ctx *Context) Record(index int, m *text.Marker) {
+	history := ctx.Nodes[index].Transforms
+	// Never append in place: the history may be shared with the caller's nodes.
+	ctx.Nodes[index].Transforms = append(history[:len(history):len(history)], text.Transform{
+		Type:   m.Type,
+		Offset: m.Offset,
+	})
+}
+
+// Handler applies the marker found at ctx.Nodes[index].
+type Handler func(ctx *Context, index int, m *text.Marker) error
+
+// Registry binds marker spellings to the handlers that apply them. A single
+// Register call makes a marker known to the lexer, the parser and the engine.
+type Registry struct {
+	syntax   *text.Syntax
+	handlers map[text.MarkerType]Handler
+}
+
+// defaultRegistry backs ApplyMarkers. It is never mutated after init.
+var defaultRegistry = NewRegistry()
+
+// NewRegistry returns a registry populated with the built-in markers.
+func NewRegistry() *Registry {
+	r := &Registry{
+		syntax:   text.DefaultSyntax(),
+		handlers: make(map[text.MarkerType]Handler),
+	}
+	r.handlers[text.MarkerHex] = numericHandler(hexToDecimal)
+	r.handlers[text.MarkerBin] = numericHandler(binToDecimal)
+	r.handlers[text.MarkerOct] = numericHandler(octToDecimal)
+	r.handlers[text.MarkerToHex] = numericHandler(decimalToHex)
+	r.handlers[text.MarkerToBin] = numericHandler(decimalToBin)
+	r.handlers[text.MarkerRoman] = numericHandler(romanToDecimal)
+	r.handlers[text.MarkerToRoman] = numericHandler(decimalToRoman)
+	r.handlers[text.MarkerWords] = numericHandler(decimalToWords)
+	r.handlers[text.MarkerDigits] = numericHandler(wordsToDecimal)
+	r.handlers[text.MarkerOrd] = numericHandler(decimalToOrdinal)
+	r.handlers[text.MarkerCalc] = applyCalc
+	r.handlers[text.MarkerDelete] = applyDelete
+	r.handlers[text.MarkerSwap] = applySwap
+	r.handlers[text.MarkerDup] = applyDuplicate
+	r.handlers[text.MarkerVar] = expandedVariable
+	r.handlers[text.MarkerUp] = caseHandler(caser.upper)
+	r.handlers[text.MarkerLow] = caseHandler(caser.lower)
+	r.handlers[text.MarkerCap] = caseHandler(caser.capitalize)
+	r.handlers[text.MarkerTitle] = applyTitleCase
+	r.handlers[text.MarkerName] = applyNameCase
+	r.handlers[text.MarkerSnake] = identifierHandler(snakeCase)
+	r.handlers[text.MarkerCamel] = identifierHandler(camelCase)
+	r.handlers[text.MarkerPascal] = identifierHandler(pascalCase)
+	r.handlers[text.MarkerKebab] = identifierHandler(kebabCase)
+	r.handlers[text.MarkerConstant] = identifierHandler(constantCase)
+
+	for _, spec := range r.syntax.Specs() {
+		if _, ok := r.handlers[spec.Type]; !ok {
+			panic(fmt.Sprintf("engine: built-in marker %q has no handler", spec.Type))
+		}
+	}
+	return r
+}
+
+// Register adds a marker named name taking arguments of the given shape.
+func (r *Registry) Register(name text.MarkerType, args text.ArgShape, h Handler) error {
+	if h == nil {
+		return fmt.Errorf("marker %q: nil handler", name)
+	}
+	if err := r.syntax.Add(text.MarkerSpec{Type: name, Args: args}); err != nil {
+		return err
+	}
+	r.handlers[name] = h
+	return nil
+}
+
+// Syntax exposes the marker spellings for text.LexWithOptions and
+// text.ParseWithOptions.
+func (r *Registry) Syntax() *text.Syntax {
+	return r.syntax
+}
+
+// Clone returns an independent copy that can be extended without affecting r.
+func (r *Registry) Clone() *Registry {
+	c := &Registry{
+		syntax:   r.syntax.Clone(),
+		handlers: make(map[text.MarkerType]Handler, len(r.handlers)),
+	}
+	for name, h := range r.handlers {
+		c.handlers[name] = h
+	}
+	return c
+}
+
+func (r *Registry) handler(name text.MarkerType) (Handler, bool) {
+	h, ok := r.handlers[name]
+	return h, ok
+}
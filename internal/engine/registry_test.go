@@ -0,0 +1,58 @@
+package engine
+
+import (
+	"testing"
+
+	"go-reloaded/internal/text"
+)
+
+func TestRegistryCustomMarker(t *testing.T) {
+	t.Parallel()
+
+	reg := NewRegistry()
+	reverse := func(ctx *Context, index int, m *text.Marker) error {
+		for _, idx := range findPreviousWord(ctx.Nodes, index, 1) {
+			runes := []rune(ctx.Nodes[idx].Value)
+			for i, j := 0, len(runes)-1; i < j; i, j = i+1, j-1 {
+				runes[i], runes[j] = runes[j], runes[i]
+			}
+			ctx.Nodes[idx].Value = string(runes)
+		}
+		return nil
+	}
+	if err := reg.Register("rev", text.ArgNone, reverse); err != nil {
+		t.Fatalf("Register: %v", err)
+	}
+
+	nodes := []text.Node{word("stressed"), marker("rev", nil)}
+	got, err := ApplyMarkersWithOptions(nodes, Options{Registry: reg})
+	if err != nil {
+		t.Fatalf("ApplyMarkersWithOptions returned error: %v", err)
+	}
+	checkWord(t, got.Nodes[0], "desserts")
+
+	if _, err := ApplyMarkers(nodes); err == nil {
+		t.Fatal("expected unknown marker error from default registry")
+	}
+}
+
+func TestRegistryRejectsDuplicates(t *testing.T) {
+	t.Parallel()
+
+	reg := NewRegistry()
+	noop := func(*Context, int, *text.Marker) error { return nil }
+	if err := reg.Register(text.MarkerUp, text.ArgCount, noop); err == nil {
+		t.Fatal("expected error when re-registering a built-in marker")
+	}
+	if err := reg.Register("shout", text.ArgCount, nil); err == nil {
+		t.Fatal("expected error for nil handler")
+	}
+
+	clone := reg.Clone()
+	if err := clone.Register("shout", text.ArgCount, noop); err != nil {
+		t.Fatalf("Register on clone: %v", err)
+	}
+	if _, ok := reg.Syntax().Lookup("shout"); ok {
+		t.Fatal("clone registration leaked into original registry")
+	}
+}
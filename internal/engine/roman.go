@@ -0,0 +1,62 @@
+package engine
+
+import (
+	"math/big"
+	"strings"
+)
+
+var romanNumerals = []struct {
+	value  int
+	symbol string
+}{
+	{1000, "M"}, {900, "CM"}, {500, "D"}, {400, "CD"},
+	{100, "C"}, {90, "XC"}, {50, "L"}, {40, "XL"},
+	{10, "X"}, {9, "IX"}, {5, "V"}, {4, "IV"}, {1, "I"},
+}
+
+const maxRoman = 3999
+
+// parseRoman reads a canonical Roman numeral in either case. Non-canonical
+// spellings such as "IIII" or "VX" are rejected.
+func parseRoman(s string) (*big.Int, bool) {
+	if s == "" {
+		return nil, false
+	}
+	upper := strings.ToUpper(s)
+	total := 0
+	rest := upper
+	for _, numeral := range romanNumerals {
+		for strings.HasPrefix(rest, numeral.symbol) {
+			total += numeral.value
+			rest = rest[len(numeral.symbol):]
+		}
+	}
+	if rest != "" || total == 0 || total > maxRoman {
+		return nil, false
+	}
+	// Round-trip to reject spellings the greedy scan tolerates, e.g. "IIII".
+	if romanString(total) != upper {
+		return nil, false
+	}
+	return big.NewInt(int64(total)), true
+}
+
+// formatRoman writes n as an upper-case Roman numeral. Only 1 to 3999 can be
+// represented.
+func formatRoman(n *big.Int) (string, bool) {
+	if !n.IsInt64() || n.Int64() < 1 || n.Int64() > maxRoman {
+		return "", false
+	}
+	return romanString(int(n.Int64())), true
+}
+
+func romanString(n int) string {
+	var b strings.Builder
+	for _, numeral := range romanNumerals {
+		for n >= numeral.value {
+			b.WriteString(numeral.symbol)
+			n -= numeral.value
+		}
+	}
+	return b.String()
+}
@@ -0,0 +1,37 @@
+package engine
+
+import "go-reloaded/internal/text"
+
+func isSentenceBoundary(n text.Node) bool {
+	return text.IsSentenceEnd(n) || text.IsParagraphBreak(n)
+}
+
+// spanWords returns the words of the sentence or paragraph (as decided by
+// isBoundary) containing the nearest word before the marker, or after it for
+// forward markers. A marker written just after a full stop therefore acts on
+// the sentence it closes.
+func spanWords(nodes []text.Node, markerIndex int, forward bool, isBoundary func(text.Node) bool) []int {
+	step := -1
+	if forward {
+		step = 1
+	}
+
+	i := markerIndex + step
+	for i >= 0 && i < len(nodes) && nodes[i].Kind != text.NodeWord {
+		i += step
+	}
+
+	var result []int
+	for ; i >= 0 && i < len(nodes) && !isBoundary(nodes[i]); i += step {
+		if nodes[i].Kind == text.NodeWord {
+			result = append(result, i)
+		}
+	}
+
+	if !forward {
+		for l, r := 0, len(result)-1; l < r; l, r = l+1, r-1 {
+			result[l], result[r] = result[r], result[l]
+		}
+	}
+	return result
+}
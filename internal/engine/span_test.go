@@ -0,0 +1,64 @@
+package engine
+
+import (
+	"testing"
+
+	"go-reloaded/internal/text"
+)
+
+func TestApplyMarkersSentenceAndParagraph(t *testing.T) {
+	t.Parallel()
+
+	nodes := []text.Node{
+		word("first"), word("sentence"), punct("."), space(),
+		word("the"), word("second"), word("one"), punct("."), space(),
+		scoped(text.MarkerCap, text.ScopeSentence, false),
+		text.Node{Kind: text.NodeSpace, Value: "\n\n"},
+		word("NEXT"), word("PARAGRAPH"), punct("."), space(), word("STILL"), word("HERE"),
+		scoped(text.MarkerLow, text.ScopeParagraph, false),
+		space(),
+		scoped(text.MarkerUp, text.ScopeSentence, true),
+		word("loud"), word("words"), punct("!"), word("quiet"),
+	}
+
+	got, err := ApplyMarkers(nodes)
+	if err != nil {
+		t.Fatalf("ApplyMarkers returned error: %v", err)
+	}
+
+	checkWord(t, got[0], "first")
+	checkWord(t, got[1], "sentence")
+	checkWord(t, got[4], "The")
+	checkWord(t, got[5], "Second")
+	checkWord(t, got[6], "One")
+	checkWord(t, got[11], "next")
+	checkWord(t, got[12], "paragraph")
+	checkWord(t, got[15], "still")
+	checkWord(t, got[16], "here")
+	checkWord(t, got[20], "LOUD")
+	checkWord(t, got[21], "WORDS")
+	checkWord(t, got[23], "quiet")
+}
+
+func TestSpanWordsStopsAtParagraphBreak(t *testing.T) {
+	t.Parallel()
+
+	nodes := []text.Node{
+		word("above"),
+		text.Node{Kind: text.NodeSpace, Value: "\n\n"},
+		word("below"), word("here"),
+		scoped(text.MarkerUp, text.ScopeSentence, false),
+	}
+
+	got := spanWords(nodes, 4, false, isSentenceBoundary)
+	if len(got) != 2 || got[0] != 2 || got[1] != 3 {
+		t.Fatalf("unexpected span: %v", got)
+	}
+}
+
+func scoped(kind text.MarkerType, scope text.MarkerScope, fwd bool) text.Node {
+	n := marker(kind, nil)
+	n.Marker.Scope = scope
+	n.Marker.Forward = fwd
+	return n
+}
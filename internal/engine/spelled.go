@@ -0,0 +1,127 @@
+package engine
+
+import (
+	"math/big"
+	"strings"
+)
+
+var smallNumbers = []string{
+	"zero", "one", "two", "three", "four", "five", "six", "seven", "eight", "nine",
+	"ten", "eleven", "twelve", "thirteen", "fourteen", "fifteen", "sixteen", "seventeen", "eighteen", "nineteen",
+}
+
+var tensNumbers = []string{
+	"", "", "twenty", "thirty", "forty", "fifty", "sixty", "seventy", "eighty", "ninety",
+}
+
+// scaleNumbers names each group of three digits, smallest first.
+var scaleNumbers = []string{"", "thousand", "million", "billion", "trillion"}
+
+// maxSpelled is the first value too large to spell out.
+var maxSpelled = new(big.Int).Exp(big.NewInt(1000), big.NewInt(int64(len(scaleNumbers))), nil)
+
+// formatWords spells n out in English, e.g. 42 as "forty-two" and -1200 as
+// "minus one thousand two hundred". Magnitudes of a quadrillion or more are
+// rejected.
+func formatWords(n *big.Int) (string, bool) {
+	abs := new(big.Int).Abs(n)
+	if abs.Cmp(maxSpelled) >= 0 {
+		return "", false
+	}
+	if abs.Sign() == 0 {
+		return smallNumbers[0], true
+	}
+
+	value := abs.Int64()
+	var groups []string
+	for scale := 0; value > 0; scale++ {
+		if group := int(value % 1000); group > 0 {
+			words := spellHundreds(group)
+			if scaleNumbers[scale] != "" {
+				words += " " + scaleNumbers[scale]
+			}
+			groups = append([]string{words}, groups...)
+		}
+		value /= 1000
+	}
+
+	spelled := strings.Join(groups, " ")
+	if n.Sign() < 0 {
+		spelled = "minus " + spelled
+	}
+	return spelled, true
+}
+
+// spellHundreds spells out 1 to 999.
+func spellHundreds(n int) string {
+	var parts []string
+	if n >= 100 {
+		parts = append(parts, smallNumbers[n/100]+" hundred")
+		n %= 100
+	}
+	switch {
+	case n == 0:
+	case n < 20:
+		parts = append(parts, smallNumbers[n])
+	case n%10 == 0:
+		parts = append(parts, tensNumbers[n/10])
+	default:
+		parts = append(parts, tensNumbers[n/10]+"-"+smallNumbers[n%10])
+	}
+	return strings.Join(parts, " ")
+}
+
+// parseWords reads a single spelled-out number word, or a hyphenated pair
+// such as "forty-two", in any letter case.
+func parseWords(s string) (*big.Int, bool) {
+	tens, ones, hyphenated := strings.Cut(strings.ToLower(s), "-")
+	if hyphenated {
+		t, ok := indexOf(tensNumbers, tens)
+		o, ok2 := indexOf(smallNumbers, ones)
+		if !ok || !ok2 || t < 2 || o < 1 || o > 9 {
+			return nil, false
+		}
+		return big.NewInt(int64(t*10 + o)), true
+	}
+
+	if n, ok := indexOf(smallNumbers, tens); ok {
+		return big.NewInt(int64(n)), true
+	}
+	if n, ok := indexOf(tensNumbers, tens); ok && n >= 2 {
+		return big.NewInt(int64(n * 10)), true
+	}
+	if tens == "hundred" {
+		return big.NewInt(100), true
+	}
+	if n, ok := indexOf(scaleNumbers, tens); ok && n > 0 {
+		return new(big.Int).Exp(big.NewInt(1000), big.NewInt(int64(n)), nil), true
+	}
+	return nil, false
+}
+
+// formatOrdinal writes n with its English ordinal suffix, e.g. 3 as "3rd"
+// and 112 as "112th".
+func formatOrdinal(n *big.Int) (string, bool) {
+	lastTwo := new(big.Int).Mod(new(big.Int).Abs(n), big.NewInt(100)).Int64()
+	suffix := "th"
+	if lastTwo < 11 || lastTwo > 13 {
+		switch lastTwo % 10 {
+		case 1:
+			suffix = "st"
+		case 2:
+			suffix = "nd"
+		case 3:
+			suffix = "rd"
+		}
+	}
+	return n.String() + suffix, true
+}
+
+func indexOf(list []string, s string) (int, bool) {
+	for i, item := range list {
+		if item != "" && item == s {
+			return i, true
+		}
+	}
+	return 0, false
+}
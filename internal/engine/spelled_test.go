@@ -0,0 +1,101 @@
+package engine
+
+import (
+	"math/big"
+	"testing"
+
+	"go-reloaded/internal/text"
+)
+
+func TestFormatWords(t *testing.T) {
+	t.Parallel()
+
+	tests := map[int64]string{
+		0:             "zero",
+		7:             "seven",
+		13:            "thirteen",
+		40:            "forty",
+		42:            "forty-two",
+		100:           "one hundred",
+		305:           "three hundred five",
+		1200:          "one thousand two hundred",
+		-21:           "minus twenty-one",
+		2_000_000_017: "two billion seventeen",
+	}
+	for in, want := range tests {
+		got, ok := formatWords(big.NewInt(in))
+		if !ok || got != want {
+			t.Errorf("formatWords(%d) = %q, %v; want %q", in, got, ok, want)
+		}
+	}
+
+	tooBig, _ := new(big.Int).SetString("1000000000000000", 10)
+	if _, ok := formatWords(tooBig); ok {
+		t.Fatal("expected a quadrillion to be out of range")
+	}
+}
+
+func TestParseWords(t *testing.T) {
+	t.Parallel()
+
+	valid := map[string]int64{
+		"zero": 0, "Eleven": 11, "forty-two": 42, "NINETY-nine": 99, "sixty": 60,
+		"hundred": 100, "thousand": 1000, "million": 1_000_000,
+	}
+	for in, want := range valid {
+		got, ok := parseWords(in)
+		if !ok || got.Int64() != want {
+			t.Errorf("parseWords(%q) = %v, %v; want %d", in, got, ok, want)
+		}
+	}
+
+	for _, in := range []string{"", "fifty-ten", "ten-one", "twenty-zero", "forty-two-one", "apple"} {
+		if got, ok := parseWords(in); ok {
+			t.Errorf("parseWords(%q) = %v, want failure", in, got)
+		}
+	}
+}
+
+func TestFormatOrdinal(t *testing.T) {
+	t.Parallel()
+
+	tests := map[int64]string{
+		1: "1st", 2: "2nd", 3: "3rd", 4: "4th", 11: "11th", 12: "12th", 13: "13th",
+		21: "21st", 102: "102nd", 111: "111th", -3: "-3rd",
+	}
+	for in, want := range tests {
+		if got, _ := formatOrdinal(big.NewInt(in)); got != want {
+			t.Errorf("formatOrdinal(%d) = %q, want %q", in, got, want)
+		}
+	}
+}
+
+func TestApplyMarkersSpelledNumbers(t *testing.T) {
+	t.Parallel()
+
+	two := 2
+	nodes := []text.Node{
+		word("21"), space(), word("5"), marker(text.MarkerWords, &two),
+		space(), word("forty"), punct("-"), word("two"), marker(text.MarkerDigits, nil),
+	}
+
+	res, err := ApplyMarkersWithOptions(nodes, Options{})
+	if err != nil {
+		t.Fatalf("ApplyMarkersWithOptions returned error: %v", err)
+	}
+	got := res.Nodes
+
+	if len(got) != 9 {
+		t.Fatalf("unexpected node count %d: %v", len(got), got)
+	}
+	checkWord(t, got[0], "twenty")
+	checkWord(t, got[2], "one")
+	checkWord(t, got[4], "five")
+	checkWord(t, got[7], "42")
+	if last, ok := got[2].LastTransform(); !ok || last.Type != text.MarkerWords {
+		t.Fatalf("expected the spelled words to record the marker, got %v", got[2].Transforms)
+	}
+	if len(res.Diagnostics) != 0 {
+		t.Fatalf("unexpected diagnostics: %v", res.Diagnostics)
+	}
+}
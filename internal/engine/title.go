@@ -0,0 +1,34 @@
+package engine
+
+import "go-reloaded/internal/text"
+
+// minorWords stay lower-case in title case unless they open or close the
+// selected run of words: articles, coordinating conjunctions and short
+// prepositions.
+var minorWords = map[string]bool{
+	"a": true, "an": true, "the": true,
+	"and": true, "but": true, "or": true, "nor": true, "for": true, "so": true, "yet": true,
+	"as": true, "at": true, "by": true, "in": true, "of": true, "off": true, "on": true,
+	"per": true, "to": true, "up": true, "via": true, "vs": true,
+	"from": true, "into": true, "onto": true, "upon": true, "with": true,
+}
+
+// applyTitleCase handles (title) and (title, n): every selected word is
+// capitalised except minor words in the middle of the run.
+func applyTitleCase(ctx *Context, index int, m *text.Marker) error {
+	wordIndices := targetWords(ctx, index, m)
+	last := len(wordIndices) - 1
+	for pos, idx := range wordIndices {
+		word := ctx.Nodes[idx].Value
+		ctx.Nodes[idx].Value = ctx.protected.protect(word, titleWord(ctx.caser, word, pos == 0 || pos == last))
+		ctx.Record(idx, m)
+	}
+	return nil
+}
+
+func titleWord(c caser, word string, edge bool) string {
+	if lower := c.lower(word); !edge && minorWords[lower] {
+		return lower
+	}
+	return c.capitalize(word)
+}
@@ -0,0 +1,44 @@
+package engine
+
+import (
+	"testing"
+
+	"go-reloaded/internal/text"
+)
+
+func TestApplyMarkersTitle(t *testing.T) {
+	t.Parallel()
+
+	five := 5
+	two := 2
+	nodes := []text.Node{
+		word("the"), word("lord"), word("of"), word("the"), word("rings"),
+		marker(text.MarkerTitle, &five),
+		word("GONE"), word("WITH"), word("THE"), word("wind"),
+		marker(text.MarkerTitle, &five),
+		word("of"), word("mice"),
+		marker(text.MarkerTitle, &two),
+		word("guide"), marker(text.MarkerTitle, nil),
+	}
+
+	got, err := ApplyMarkers(nodes)
+	if err != nil {
+		t.Fatalf("ApplyMarkers returned error: %v", err)
+	}
+
+	want := []string{"The", "Lord", "of", "the", "Rings"}
+	for i, w := range want {
+		checkWord(t, got[i], w)
+	}
+	checkWord(t, got[6], "Gone")
+	checkWord(t, got[7], "with")
+	checkWord(t, got[8], "the")
+	checkWord(t, got[9], "Wind")
+	checkWord(t, got[11], "Of")
+	checkWord(t, got[12], "Mice")
+	checkWord(t, got[14], "Guide")
+
+	if last, ok := got[2].LastTransform(); !ok || last.Type != text.MarkerTitle {
+		t.Fatalf("expected title case transform, got %v", got[2].Transforms)
+	}
+}
@@ -0,0 +1,69 @@
+package engine
+
+import (
+	"fmt"
+
+	"go-reloaded/internal/diag"
+	"go-reloaded/internal/text"
+)
+
+// plainSyntax recognises no markers, so variable values and the text added
+// by macros are always literal.
+var plainSyntax = &text.Syntax{}
+
+// expandVariables replaces every (var, name) marker before any other marker
+// runs, so forward markers, sentence scopes and (begin x) regions written
+// around a variable see its value.
+func expandVariables(ctx *Context) error {
+	// Walk backwards so each expansion leaves earlier indices unchanged.
+	for i := len(ctx.Nodes) - 1; i >= 0; i-- {
+		node := ctx.Nodes[i]
+		if node.Kind != text.NodeMarker || node.Marker == nil || node.Marker.Type != text.MarkerVar {
+			continue
+		}
+		if err := applyVariable(ctx, i, node.Marker); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// expandedVariable is the handler for (var, name). Defined variables were
+// already expanded by expandVariables, which also reported undefined ones.
+func expandedVariable(*Context, int, *text.Marker) error {
+	return nil
+}
+
+// applyVariable replaces the (var, name) marker at index with the words of
+// the variable's value, which markers and the remaining stages then treat
+// like any other text. Markers written inside a value stay literal.
+func applyVariable(ctx *Context, index int, m *text.Marker) error {
+	value, ok := ctx.vars[m.Arg]
+	if !ok {
+		ctx.Report(index, diag.CodeUndefinedVariable, "variable %q is not defined", m.Arg)
+		return nil
+	}
+
+	nodes, err := plainNodes(value)
+	if err != nil {
+		return fmt.Errorf("variable %q: %w", m.Arg, err)
+	}
+
+	ctx.Splice(index, index+1, nodes...)
+	for i, node := range nodes {
+		if node.Kind == text.NodeWord {
+			ctx.Record(index+i, m)
+		}
+	}
+	return nil
+}
+
+// plainNodes parses s as text in which no markers are recognised.
+func plainNodes(s string) ([]text.Node, error) {
+	opts := text.Options{Syntax: plainSyntax}
+	tokens, err := text.LexWithOptions(s, opts)
+	if err != nil {
+		return nil, err
+	}
+	return text.ParseWithOptions(tokens, opts)
+}
@@ -0,0 +1,71 @@
+package engine
+
+import (
+	"testing"
+
+	"go-reloaded/internal/diag"
+	"go-reloaded/internal/text"
+)
+
+func variable(name string) text.Node {
+	return text.Node{Kind: text.NodeMarker, Marker: &text.Marker{Type: text.MarkerVar, Arg: name}}
+}
+
+func TestApplyMarkersVariables(t *testing.T) {
+	t.Parallel()
+
+	two := 2
+	nodes := []text.Node{
+		word("try"), space(), variable("product"), marker(text.MarkerUp, &two),
+		space(), variable("missing"), space(), variable("literal"),
+	}
+	vars := map[string]string{"product": "acme widget", "literal": "(up)"}
+
+	res, err := ApplyMarkersWithOptions(nodes, Options{Vars: vars})
+	if err != nil {
+		t.Fatalf("ApplyMarkersWithOptions returned error: %v", err)
+	}
+	got := res.Nodes
+
+	checkWord(t, got[0], "try")
+	checkWord(t, got[2], "ACME")
+	checkWord(t, got[4], "WIDGET")
+	if last, ok := got[4].LastTransform(); !ok || last.Type != text.MarkerUp || len(got[4].Transforms) != 2 {
+		t.Fatalf("expected (var) then (up) in the history, got %v", got[4].Transforms)
+	}
+	if got[7].Kind != text.NodeMarker {
+		t.Fatalf("expected the undefined variable to stay a marker, got %v", got[7])
+	}
+	// Markers inside a value are literal text.
+	if got[9].Kind != text.NodePunct || got[9].Value != "(" {
+		t.Fatalf("expected a literal parenthesis, got %v", got[9])
+	}
+	checkWord(t, got[10], "up")
+
+	if len(res.Diagnostics) != 1 || res.Diagnostics[0].Code != diag.CodeUndefinedVariable {
+		t.Fatalf("expected one undefined-variable diagnostic, got %v", res.Diagnostics)
+	}
+	if res.Diagnostics[0].Msg != `variable "missing" is not defined` {
+		t.Fatalf("unexpected message: %q", res.Diagnostics[0].Msg)
+	}
+}
+
+func TestVariablesExpandBeforeOtherMarkers(t *testing.T) {
+	t.Parallel()
+
+	nodes := []text.Node{
+		block(text.MarkerUp, text.ScopeBlockBegin), space(), variable("p"), space(), block(text.MarkerUp, text.ScopeBlockEnd),
+		space(), forward(text.MarkerCap, nil), space(), variable("p"), space(), word("x"),
+	}
+
+	res, err := ApplyMarkersWithOptions(nodes, Options{Vars: map[string]string{"p": "foo"}})
+	if err != nil {
+		t.Fatalf("ApplyMarkersWithOptions returned error: %v", err)
+	}
+	checkWord(t, res.Nodes[2], "FOO")
+	checkWord(t, res.Nodes[8], "Foo")
+	checkWord(t, res.Nodes[10], "x")
+	if len(res.Diagnostics) != 0 {
+		t.Fatalf("unexpected diagnostics: %v", res.Diagnostics)
+	}
+}
@@ -27,7 +27,8 @@ func FixArticles(nodes []text.Node) []text.Node {
 
 		nextWord := out[nextIdx].Value
 		if beginsWithVowelOrH(nextWord) {
-			wasUppercased := node.CaseTransform != nil && *node.CaseTransform == text.MarkerUp
+			last, ok := node.LastTransform()
+			wasUppercased := ok && last.Type == text.MarkerUp
 			out[i].Value = convertArticle(node.Value, wasUppercased)
 		}
 	}
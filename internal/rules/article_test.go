@@ -55,6 +55,38 @@ func TestFixArticles(t *testing.T) {
 	}
 }
 
+func TestFixArticlesUsesLatestTransform(t *testing.T) {
+	t.Parallel()
+
+	article := func(history ...text.MarkerType) text.Node {
+		n := text.Node{Kind: text.NodeWord, Value: "A"}
+		for i, kind := range history {
+			n.Transforms = append(n.Transforms, text.Transform{Type: kind, Offset: i})
+		}
+		return n
+	}
+	apple := text.Node{Kind: text.NodeWord, Value: "APPLE"}
+	space := text.Node{Kind: text.NodeSpace, Value: " "}
+
+	cases := []struct {
+		name    string
+		article text.Node
+		want    string
+	}{
+		{name: "upper-cased", article: article(text.MarkerUp), want: "AN"},
+		{name: "capitalised after upper-casing", article: article(text.MarkerUp, text.MarkerCap), want: "An"},
+		{name: "upper-cased after capitalising", article: article(text.MarkerCap, text.MarkerUp), want: "AN"},
+		{name: "never transformed", article: article(), want: "An"},
+	}
+
+	for _, tc := range cases {
+		got := FixArticles([]text.Node{tc.article, space, apple})
+		if got[0].Value != tc.want {
+			t.Fatalf("%s: want %q, got %q", tc.name, tc.want, got[0].Value)
+		}
+	}
+}
+
 func parseNodes(t *testing.T, input string) []text.Node {
 	t.Helper()
 	tokens, err := text.Lex(input)
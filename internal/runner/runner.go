@@ -4,60 +4,205 @@ package runner
 import (
 	"fmt"
 	"io"
+	"sort"
 	"strings"
 
+	"go-reloaded/internal/diag"
 	"go-reloaded/internal/engine"
 	"go-reloaded/internal/punct"
 	"go-reloaded/internal/rules"
 	"go-reloaded/internal/text"
 )
 
+// Options configures RunWithOptions.
+type Options struct {
+	// Registry supplies the recognised markers and their handlers. Nil means
+	// the built-in markers.
+	Registry *engine.Registry
+	// Strict turns marker warnings into a *StrictError.
+	Strict bool
+	// Lenient accepts marker spellings that differ from the canonical form
+	// in whitespace or letter case, noting each one as a diagnostic.
+	Lenient bool
+	// Locale selects locale-specific case mappings such as Turkish "tr".
+	Locale string
+	// Protected lists words whose spelling case markers keep. Nil means
+	// engine.DefaultProtectedWords.
+	Protected engine.ProtectedWords
+	// Vars holds the values that (var, name) markers expand to.
+	Vars map[string]string
+	// Compounds counts hyphen- and slash-joined compounds as single words.
+	Compounds bool
+}
+
+// StrictError is returned in strict mode when any marker was invalid, had
+// nothing to act on or was only partly applied.
+type StrictError struct {
+	Diagnostics []diag.Diagnostic
+}
+
+func (e *StrictError) Error() string {
+	if len(e.Diagnostics) == 1 {
+		return "strict mode: 1 marker could not be applied"
+	}
+	return fmt.Sprintf("strict mode: %d markers could not be applied", len(e.Diagnostics))
+}
+
+// Result is the outcome of RunWithOptions.
+type Result struct {
+	Output string
+	// Diagnostics lists markers that were invalid, had nothing to act on or
+	// were only partly applied, plus notes about non-canonical spellings,
+	// ordered by byte offset.
+	Diagnostics []diag.Diagnostic
+}
+
 // Run executes the text formatting pipeline: lexing, parsing, marker
 // transformations, and reconstruction. Spacing and punctuation clean-up are
 // handled in later stages.
 func Run(r io.Reader) (string, error) {
+	res, err := RunWithOptions(r, Options{})
+	if err != nil {
+		return "", err
+	}
+	return res.Output, nil
+}
+
+// RunWithOptions is Run with caller-supplied configuration. It also returns
+// the diagnostics collected while applying markers.
+func RunWithOptions(r io.Reader, opts Options) (Result, error) {
 	data, err := io.ReadAll(r)
 	if err != nil {
-		return "", fmt.Errorf("read input: %w", err)
+		return Result{}, fmt.Errorf("read input: %w", err)
 	}
 
 	input := string(data)
 
-	tokens, err := text.Lex(input)
+	registry := opts.Registry
+	if registry == nil {
+		registry = engine.NewRegistry()
+	}
+	textOpts := text.Options{Syntax: registry.Syntax(), Lenient: opts.Lenient}
+
+	tokens, err := text.LexWithOptions(input, textOpts)
 	if err != nil {
-		return "", fmt.Errorf("lex: %w", err)
+		return Result{}, fmt.Errorf("lex: %w", err)
 	}
 
-	nodes, err := text.Parse(tokens)
+	nodes, err := text.ParseWithOptions(tokens, textOpts)
 	if err != nil {
-		return "", fmt.Errorf("parse: %w", err)
+		return Result{}, fmt.Errorf("parse: %w", err)
 	}
 
-	transformed, err := engine.ApplyMarkers(nodes)
+	transformed, err := engine.ApplyMarkersWithOptions(nodes, engine.Options{
+		Registry:  registry,
+		Locale:    opts.Locale,
+		Protected: opts.Protected,
+		Vars:      opts.Vars,
+		Compounds: opts.Compounds,
+	})
 	if err != nil {
-		return "", fmt.Errorf("transform: %w", err)
+		return Result{}, fmt.Errorf("transform: %w", err)
 	}
 
-	normalized := punct.Normalize(transformed)
+	var diagnostics []diag.Diagnostic
+	if opts.Lenient {
+		diagnostics = append(diagnostics, text.SpellingDiagnostics(nodes)...)
+	}
+	diagnostics = append(diagnostics, transformed.Diagnostics...)
+	sort.SliceStable(diagnostics, func(i, j int) bool {
+		return diagnostics[i].Offset < diagnostics[j].Offset
+	})
+
+	if opts.Strict {
+		var warnings []diag.Diagnostic
+		for _, d := range diagnostics {
+			if d.Severity == diag.SeverityWarning {
+				warnings = append(warnings, d)
+			}
+		}
+		if len(warnings) > 0 {
+			return Result{Diagnostics: diagnostics}, &StrictError{Diagnostics: warnings}
+		}
+	}
+
+	normalized := punct.Normalize(transformed.Nodes)
 
 	withArticles := rules.FixArticles(normalized)
 
-	return Reconstruct(withArticles), nil
+	return Result{
+		Output:      Reconstruct(withArticles),
+		Diagnostics: diagnostics,
+	}, nil
+}
+
+// FormatMarkers rewrites every marker in the input to its canonical spelling
+// and leaves all other bytes untouched. Markers are recognised leniently, so
+// "(up,3)" becomes "(up, 3)" and "( cap )" becomes "(cap)". Markers are not
+// applied.
+func FormatMarkers(r io.Reader, opts Options) (string, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return "", fmt.Errorf("read input: %w", err)
+	}
+
+	input := string(data)
+
+	registry := opts.Registry
+	if registry == nil {
+		registry = engine.NewRegistry()
+	}
+	textOpts := text.Options{Syntax: registry.Syntax(), Lenient: true}
+
+	tokens, err := text.LexWithOptions(input, textOpts)
+	if err != nil {
+		return "", fmt.Errorf("lex: %w", err)
+	}
+
+	nodes, err := text.ParseWithOptions(tokens, textOpts)
+	if err != nil {
+		return "", fmt.Errorf("parse: %w", err)
+	}
+
+	// Parse emits exactly one node per token, so indices line up.
+	var b strings.Builder
+	last := 0
+	for i, tok := range tokens {
+		if tok.Kind != text.TokenMarker {
+			continue
+		}
+		b.WriteString(input[last:tok.Start])
+		b.WriteString(nodes[i].Marker.String())
+		last = tok.End
+	}
+	b.WriteString(input[last:])
+
+	return b.String(), nil
 }
 
 // Reconstruct renders the node list back into string form, omitting marker
 // nodes while preserving spacing decisions made by downstream passes.
 func Reconstruct(nodes []text.Node) string {
 	filtered := make([]text.Node, 0, len(nodes))
+	lineStartMarker := false
 	for _, node := range nodes {
 		if node.Kind == text.NodeMarker {
 			if len(filtered) > 0 && filtered[len(filtered)-1].Kind == text.NodeSpace {
 				if !strings.ContainsAny(filtered[len(filtered)-1].Value, "\n\r") {
 					filtered = filtered[:len(filtered)-1]
+					continue
 				}
 			}
+			// A marker opening a line, such as "(up>) start", takes the
+			// space after it instead.
+			lineStartMarker = len(filtered) == 0 || filtered[len(filtered)-1].Kind == text.NodeSpace || lineStartMarker
+			continue
+		}
+		if lineStartMarker && node.Kind == text.NodeSpace && !strings.ContainsAny(node.Value, "\n\r") {
+			lineStartMarker = false
 			continue
 		}
+		lineStartMarker = false
 		filtered = append(filtered, node)
 	}
 
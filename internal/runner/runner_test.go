@@ -1,8 +1,13 @@
 package runner
 
 import (
+	"errors"
 	"strings"
 	"testing"
+
+	"go-reloaded/internal/diag"
+	"go-reloaded/internal/engine"
+	"go-reloaded/internal/text"
 )
 
 func TestRunAppliesMarkers(t *testing.T) {
@@ -28,6 +33,26 @@ func TestRunAppliesMarkers(t *testing.T) {
 			input: "We added 1E (hex) files",
 			want:  "We added 30 files",
 		},
+		{
+			name:  "hex conversion beyond 64 bits",
+			input: "FFFFFFFFFFFFFFFFFF (hex) bytes",
+			want:  "4722366482869645213695 bytes",
+		},
+		{
+			name:  "prefixed, signed and separated numbers",
+			input: "set 0x1F (hex), -1A (hex), 0b1010 (bin) and 1111_0000 (bin)",
+			want:  "set 31, -26, 10 and 240",
+		},
+		{
+			name:  "octal, reverse and roman conversions",
+			input: "mode 755 (oct), byte 255 (tohex), flags 5 (tobin), chapter XIV (roman), year 2024 (toroman)",
+			want:  "mode 493, byte FF, flags 101, chapter 14, year MMXXIV",
+		},
+		{
+			name:  "counted hex conversion",
+			input: "row FF 10 1A (hex, 3) end",
+			want:  "row 255 16 26 end",
+		},
 		{
 			name:  "capitalise multiple words",
 			input: "the brooklyn bridge (cap, 2)",
@@ -38,6 +63,16 @@ func TestRunAppliesMarkers(t *testing.T) {
 			input: "KEEP IT DOWN (low, 2) please.",
 			want:  "KEEP it down please.",
 		},
+		{
+			name:  "title case keeps minor words lower",
+			input: "the lord of the rings (title, 5)",
+			want:  "The Lord of the Rings",
+		},
+		{
+			name:  "title case feeds article correction",
+			input: "a apple a day (title, 4)",
+			want:  "An Apple a Day",
+		},
 		{
 			name:  "contraction treated as one word",
 			input: "it's (up) nice",
@@ -53,6 +88,26 @@ func TestRunAppliesMarkers(t *testing.T) {
 			input: "state-of-the-art (up)\n\n(up)start here",
 			want:  "state-of-the-ART\n\nstart here",
 		},
+		{
+			name:  "forward marker at start of line",
+			input: "intro\n(up>) start here (cap>, 2) next words",
+			want:  "intro\nSTART here Next Words",
+		},
+		{
+			name:  "block markers cover a region",
+			input: "(begin up) all of this, (begin low) BUT NOT THIS (end low) and this (end up) done",
+			want:  "ALL OF THIS, but not this AND THIS done",
+		},
+		{
+			name:  "sentence and paragraph scopes",
+			input: "keep this. make this a title (cap, s).\n\nSHOUTED LINE ONE.\nSHOUTED LINE TWO. (low, p)",
+			want:  "keep this. Make This A Title.\n\nshouted line one.\nshouted line two.",
+		},
+		{
+			name:  "escaped marker stays literal",
+			input: `write \(up) after a word (cap, 3) to shout`,
+			want:  "write (up) After A Word to shout",
+		},
 		{
 			name:  "article correction with punctuation",
 			input: "There is ... a amazing rock!",
@@ -85,3 +140,336 @@ func TestRunPropagatesErrors(t *testing.T) {
 		t.Fatalf("expected empty output, got %q", got)
 	}
 }
+
+func TestRunWithCustomMarker(t *testing.T) {
+	t.Parallel()
+
+	reg := engine.NewRegistry()
+	exclaim := func(ctx *engine.Context, index int, m *text.Marker) error {
+		for _, i := range ctx.Targets(index, m) {
+			ctx.Nodes[i].Value += "!"
+			ctx.Record(i, m)
+		}
+		return nil
+	}
+	if err := reg.Register("bang", text.ArgCount, exclaim); err != nil {
+		t.Fatalf("Register: %v", err)
+	}
+
+	input := "wow (bang) (up) that works (bang>, 2) so well (begin bang) a b (end bang)"
+	res, err := RunWithOptions(strings.NewReader(input), Options{Registry: reg, Strict: true})
+	if err != nil {
+		t.Fatalf("RunWithOptions returned error: %v", err)
+	}
+	if want := "WOW! that works so! well! a! b!"; res.Output != want {
+		t.Fatalf("unexpected output:\nwant %q\ngot  %q", want, res.Output)
+	}
+}
+
+func TestRunReportsDiagnostics(t *testing.T) {
+	t.Parallel()
+
+	input := "(up) Good morning (up, -1) and zz (hex) then (cap, 9) (begin low) x"
+	res, err := RunWithOptions(strings.NewReader(input), Options{})
+	if err != nil {
+		t.Fatalf("RunWithOptions returned error: %v", err)
+	}
+
+	want := []diag.Diagnostic{
+		{Offset: 0, Marker: "(up)", Code: diag.CodeNoTarget, Msg: "no preceding word to act on"},
+		{Offset: 18, Marker: "(up, -1)", Code: diag.CodeInvalidCount, Msg: "count must be positive, got -1"},
+		{Offset: 34, Marker: "(hex)", Code: diag.CodeInvalidNumber, Msg: `"zz" is not a valid hexadecimal number`},
+		{Offset: 45, Marker: "(cap, 9)", Code: diag.CodePartial, Msg: "only 5 of 9 words available"},
+		{Offset: 54, Marker: "(begin low)", Code: diag.CodeUnbalancedBlock, Msg: "no matching (end low)"},
+	}
+	if len(res.Diagnostics) != len(want) {
+		t.Fatalf("expected %d diagnostics, got %v", len(want), res.Diagnostics)
+	}
+	for i := range want {
+		if res.Diagnostics[i] != want[i] {
+			t.Fatalf("diagnostic %d:\nwant %+v\ngot  %+v", i, want[i], res.Diagnostics[i])
+		}
+	}
+}
+
+func TestRunStrictMode(t *testing.T) {
+	t.Parallel()
+
+	res, err := RunWithOptions(strings.NewReader("Good morning (up, -1)"), Options{Strict: true})
+	var strictErr *StrictError
+	if !errors.As(err, &strictErr) {
+		t.Fatalf("expected *StrictError, got %v", err)
+	}
+	if len(strictErr.Diagnostics) != 1 || strictErr.Diagnostics[0].Code != diag.CodeInvalidCount {
+		t.Fatalf("unexpected diagnostics: %v", strictErr.Diagnostics)
+	}
+	if res.Output != "" {
+		t.Fatalf("expected no output in strict failure, got %q", res.Output)
+	}
+
+	res, err = RunWithOptions(strings.NewReader("Good morning (up)"), Options{Strict: true})
+	if err != nil {
+		t.Fatalf("RunWithOptions returned error for clean input: %v", err)
+	}
+	if res.Output != "Good MORNING" {
+		t.Fatalf("unexpected output: %q", res.Output)
+	}
+}
+
+func TestRunLenientMarkers(t *testing.T) {
+	t.Parallel()
+
+	input := "Hello world(up,2)\n\nGood morning ( up,1 ) and (CAP)"
+
+	strictRes, err := RunWithOptions(strings.NewReader(input), Options{})
+	if err != nil {
+		t.Fatalf("RunWithOptions returned error: %v", err)
+	}
+	if want := "Hello world(up,2)\n\nGood morning ( up,1 ) and (CAP)"; strictRes.Output != want {
+		t.Fatalf("default mode must leave variants alone, got %q", strictRes.Output)
+	}
+
+	res, err := RunWithOptions(strings.NewReader(input), Options{Lenient: true, Strict: true})
+	if err != nil {
+		t.Fatalf("lenient notes must not fail strict mode: %v", err)
+	}
+	if want := "HELLO WORLD\n\nGood MORNING And"; res.Output != want {
+		t.Fatalf("unexpected output:\nwant %q\ngot  %q", want, res.Output)
+	}
+	if len(res.Diagnostics) != 3 {
+		t.Fatalf("expected three notes, got %v", res.Diagnostics)
+	}
+	for _, d := range res.Diagnostics {
+		if d.Code != diag.CodeNonCanonical || d.Severity != diag.SeverityNote {
+			t.Fatalf("unexpected diagnostic: %+v", d)
+		}
+	}
+}
+
+func TestRunCompounds(t *testing.T) {
+	t.Parallel()
+
+	input := "the state-of-the-art method (title, 3) and/or (up)"
+
+	plain, err := RunWithOptions(strings.NewReader(input), Options{})
+	if err != nil {
+		t.Fatalf("RunWithOptions returned error: %v", err)
+	}
+	if want := "the state-of-The-Art Method and/OR"; plain.Output != want {
+		t.Fatalf("unexpected default output:\nwant %q\ngot  %q", want, plain.Output)
+	}
+
+	res, err := RunWithOptions(strings.NewReader(input), Options{Compounds: true})
+	if err != nil {
+		t.Fatalf("RunWithOptions returned error: %v", err)
+	}
+	if want := "The State-of-the-Art Method AND/OR"; res.Output != want {
+		t.Fatalf("unexpected output:\nwant %q\ngot  %q", want, res.Output)
+	}
+}
+
+func TestRunLocale(t *testing.T) {
+	t.Parallel()
+
+	input := "istanbul (cap) DIYARBAKIR (low)"
+	res, err := RunWithOptions(strings.NewReader(input), Options{Locale: "tr"})
+	if err != nil {
+		t.Fatalf("RunWithOptions returned error: %v", err)
+	}
+	if want := "İstanbul dıyarbakır"; res.Output != want {
+		t.Fatalf("unexpected output:\nwant %q\ngot  %q", want, res.Output)
+	}
+
+	if _, err := RunWithOptions(strings.NewReader(input), Options{Locale: "??"}); err == nil {
+		t.Fatal("expected an error for an invalid locale")
+	}
+}
+
+func TestRunProtectedWords(t *testing.T) {
+	t.Parallel()
+
+	input := "nasa launched iphone (cap, 3) and NATO (low)"
+	res, err := RunWithOptions(strings.NewReader(input), Options{})
+	if err != nil {
+		t.Fatalf("RunWithOptions returned error: %v", err)
+	}
+	if want := "NASA Launched iPhone and NATO"; res.Output != want {
+		t.Fatalf("unexpected output:\nwant %q\ngot  %q", want, res.Output)
+	}
+
+	protected := engine.ProtectedWords{}
+	protected.Add("Launched")
+	res, err = RunWithOptions(strings.NewReader(input), Options{Protected: protected})
+	if err != nil {
+		t.Fatalf("RunWithOptions returned error: %v", err)
+	}
+	if want := "Nasa Launched Iphone and nato"; res.Output != want {
+		t.Fatalf("unexpected output:\nwant %q\ngot  %q", want, res.Output)
+	}
+}
+
+func TestRunNameMarker(t *testing.T) {
+	t.Parallel()
+
+	got, err := Run(strings.NewReader("o'neil mcdonald van der berg (name, 4) met vincent van gogh (name, 3)."))
+	if err != nil {
+		t.Fatalf("Run returned error: %v", err)
+	}
+	if want := "O'Neil McDonald van der Berg met Vincent van Gogh."; got != want {
+		t.Fatalf("unexpected output:\nwant %q\ngot  %q", want, got)
+	}
+}
+
+func TestRunIdentifierMarkers(t *testing.T) {
+	t.Parallel()
+
+	got, err := Run(strings.NewReader("Set user account id (snake, 3) from user account id (camel, 3) , then save."))
+	if err != nil {
+		t.Fatalf("Run returned error: %v", err)
+	}
+	if want := "Set user_account_id from userAccountId, then save."; got != want {
+		t.Fatalf("unexpected output:\nwant %q\ngot  %q", want, got)
+	}
+}
+
+func TestRunSpelledNumbers(t *testing.T) {
+	t.Parallel()
+
+	got, err := Run(strings.NewReader("It took a 8 (words) hour drive, forty-two (digits) miles, on the 3 (ord) try."))
+	if err != nil {
+		t.Fatalf("Run returned error: %v", err)
+	}
+	if want := "It took an eight hour drive, 42 miles, on the 3rd try."; got != want {
+		t.Fatalf("unexpected output:\nwant %q\ngot  %q", want, got)
+	}
+}
+
+func TestRunCalc(t *testing.T) {
+	t.Parallel()
+
+	res, err := RunWithOptions(strings.NewReader("We need (2 + 3) * 4 (calc) chairs, not 5/0 (calc)."), Options{})
+	if err != nil {
+		t.Fatalf("RunWithOptions returned error: %v", err)
+	}
+	if want := "We need 20 chairs, not 5/0."; res.Output != want {
+		t.Fatalf("unexpected output:\nwant %q\ngot  %q", want, res.Output)
+	}
+	if len(res.Diagnostics) != 1 || res.Diagnostics[0].Code != diag.CodeInvalidExpression {
+		t.Fatalf("expected one invalid-expression diagnostic, got %v", res.Diagnostics)
+	}
+}
+
+func TestRunCalcInsideParentheses(t *testing.T) {
+	t.Parallel()
+
+	input := "(total 2+3) (calc) and (calc>) (4*5 in all)"
+	res, err := RunWithOptions(strings.NewReader(input), Options{Strict: true})
+	if err != nil {
+		t.Fatalf("RunWithOptions returned error: %v", err)
+	}
+	if want := "(total 5) and (20 in all)"; res.Output != want {
+		t.Fatalf("unexpected output:\nwant %q\ngot  %q", want, res.Output)
+	}
+}
+
+func TestRunEditingMarkers(t *testing.T) {
+	t.Parallel()
+
+	input := "This is is (del) a draft very draft (del, 2) , hello world (swap) and very (dup) good ."
+	got, err := Run(strings.NewReader(input))
+	if err != nil {
+		t.Fatalf("Run returned error: %v", err)
+	}
+	if want := "This is a draft, world hello and very very good."; got != want {
+		t.Fatalf("unexpected output:\nwant %q\ngot  %q", want, got)
+	}
+}
+
+func TestRunVariables(t *testing.T) {
+	t.Parallel()
+
+	input := "Meet (var, product) (cap, 2), a (var, adjective) tool by (var, vendor)."
+	vars := map[string]string{"product": "acme widget", "adjective": "open"}
+	res, err := RunWithOptions(strings.NewReader(input), Options{Vars: vars})
+	if err != nil {
+		t.Fatalf("RunWithOptions returned error: %v", err)
+	}
+	if want := "Meet Acme Widget, an open tool by."; res.Output != want {
+		t.Fatalf("unexpected output:\nwant %q\ngot  %q", want, res.Output)
+	}
+	if len(res.Diagnostics) != 1 || res.Diagnostics[0].Code != diag.CodeUndefinedVariable {
+		t.Fatalf("expected one undefined-variable diagnostic, got %v", res.Diagnostics)
+	}
+}
+
+func TestRunMacros(t *testing.T) {
+	t.Parallel()
+
+	reg := engine.NewRegistry()
+	if err := reg.LoadMacros(strings.NewReader("shout = up\nheading = title then trailing \":\"")); err != nil {
+		t.Fatalf("LoadMacros returned error: %v", err)
+	}
+
+	input := "a overview of the plan (heading, 5)\nstop (shout) now"
+	res, err := RunWithOptions(strings.NewReader(input), Options{Registry: reg})
+	if err != nil {
+		t.Fatalf("RunWithOptions returned error: %v", err)
+	}
+	if want := "An Overview of the Plan:\nSTOP now"; res.Output != want {
+		t.Fatalf("unexpected output:\nwant %q\ngot  %q", want, res.Output)
+	}
+}
+
+func TestRunMacrosAfterMergingStep(t *testing.T) {
+	t.Parallel()
+
+	reg := engine.NewRegistry()
+	if err := reg.LoadMacros(strings.NewReader("id = snake then trailing \":\"\ndrop = del then trailing \"...\"")); err != nil {
+		t.Fatalf("LoadMacros returned error: %v", err)
+	}
+
+	tests := []struct {
+		input  string
+		expect string
+	}{
+		{"the user account id (id, 3) is set", "the user_account_id: is set"},
+		{"(id>, 3) user account id is set", "user_account_id: is set"},
+		{"it was very very (drop) good", "it was very... good"},
+	}
+	for _, tc := range tests {
+		res, err := RunWithOptions(strings.NewReader(tc.input), Options{Registry: reg, Strict: true})
+		if err != nil {
+			t.Fatalf("RunWithOptions(%q) returned error: %v", tc.input, err)
+		}
+		if res.Output != tc.expect {
+			t.Fatalf("RunWithOptions(%q):\nwant %q\ngot  %q", tc.input, tc.expect, res.Output)
+		}
+	}
+}
+
+func TestRunVariablesInsideRegions(t *testing.T) {
+	t.Parallel()
+
+	input := "(begin up) (var, p) (end up) and (cap>) (var, p) x"
+	res, err := RunWithOptions(strings.NewReader(input), Options{Vars: map[string]string{"p": "foo"}, Strict: true})
+	if err != nil {
+		t.Fatalf("RunWithOptions returned error: %v", err)
+	}
+	if want := "FOO and Foo x"; res.Output != want {
+		t.Fatalf("unexpected output:\nwant %q\ngot  %q", want, res.Output)
+	}
+}
+
+func TestFormatMarkers(t *testing.T) {
+	t.Parallel()
+
+	input := "Hello  world(up,3) ,keep\n( cap ) x (Title, S) y \\( up ) (begin  LOW) (up>,2) ( nope )"
+	got, err := FormatMarkers(strings.NewReader(input), Options{})
+	if err != nil {
+		t.Fatalf("FormatMarkers returned error: %v", err)
+	}
+	want := "Hello  world(up, 3) ,keep\n(cap) x (title, s) y \\( up ) (begin low) (up>, 2) ( nope )"
+	if got != want {
+		t.Fatalf("unexpected output:\nwant %q\ngot  %q", want, got)
+	}
+}
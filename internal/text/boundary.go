@@ -0,0 +1,25 @@
+package text
+
+import "strings"
+
+// IsSentenceEnd reports whether n is punctuation that closes a sentence.
+func IsSentenceEnd(n Node) bool {
+	if n.Kind != NodePunct {
+		return false
+	}
+	switch n.Value {
+	case ".", "!", "?", "...", "!?":
+		return true
+	default:
+		return false
+	}
+}
+
+// IsParagraphBreak reports whether n is whitespace containing a blank line.
+func IsParagraphBreak(n Node) bool {
+	if n.Kind != NodeSpace {
+		return false
+	}
+	value := strings.ReplaceAll(n.Value, "\r\n", "\n")
+	return strings.Count(value, "\n")+strings.Count(value, "\r") >= 2
+}
@@ -0,0 +1,35 @@
+package text
+
+import "testing"
+
+func TestBoundaries(t *testing.T) {
+	t.Parallel()
+
+	sentenceEnds := []Node{
+		{Kind: NodePunct, Value: "."},
+		{Kind: NodePunct, Value: "!?"},
+		{Kind: NodePunct, Value: "..."},
+	}
+	for _, n := range sentenceEnds {
+		if !IsSentenceEnd(n) {
+			t.Fatalf("expected %q to end a sentence", n.Value)
+		}
+	}
+	for _, n := range []Node{{Kind: NodePunct, Value: ","}, {Kind: NodeWord, Value: "."}} {
+		if IsSentenceEnd(n) {
+			t.Fatalf("did not expect %s(%q) to end a sentence", n.Kind, n.Value)
+		}
+	}
+
+	breaks := []string{"\n\n", "\n  \n", "\r\n\r\n", "\n\n\n"}
+	for _, v := range breaks {
+		if !IsParagraphBreak(Node{Kind: NodeSpace, Value: v}) {
+			t.Fatalf("expected %q to break paragraphs", v)
+		}
+	}
+	for _, v := range []string{" ", "\n", "\r\n", " \n "} {
+		if IsParagraphBreak(Node{Kind: NodeSpace, Value: v}) {
+			t.Fatalf("did not expect %q to break paragraphs", v)
+		}
+	}
+}
@@ -9,12 +9,23 @@ import (
 )
 
 var (
-	strictSimpleMarkers = []string{"(hex)", "(bin)", "(up)", "(low)", "(cap)"}
-	strictCountPattern  = regexp.MustCompile(`^\((up|low|cap), -?\d+\)`)
+	strictMarkerPattern  = regexp.MustCompile(`^\(([a-z][a-z0-9_]*)(>?)(?:, ([^\s(),]+))?\)`)
+	blockMarkerPattern   = regexp.MustCompile(`^\((begin|end) ([a-z][a-z0-9_]*)\)`)
+	lenientMarkerPattern = regexp.MustCompile(`^\([ \t]*([A-Za-z][A-Za-z0-9_]*)[ \t]*(>?)[ \t]*(?:,[ \t]*([^\s(),]+)[ \t]*)?\)`)
+	lenientBlockPattern  = regexp.MustCompile(`^\([ \t]*((?i:begin|end))[ \t]+([A-Za-z][A-Za-z0-9_]*)[ \t]*\)`)
+	countPattern         = regexp.MustCompile(`^(-?\d+|s|p)$`)
 )
 
-// Lex tokenises the supplied input into a stable sequence of Tokens.
+// Lex tokenises the supplied input into a stable sequence of Tokens using the
+// built-in marker syntax.
 func Lex(input string) ([]Token, error) {
+	return LexWithOptions(input, Options{})
+}
+
+// LexWithOptions tokenises input, recognising the markers listed in
+// opts.Syntax.
+func LexWithOptions(input string, opts Options) ([]Token, error) {
+	syntax := opts.syntax()
 	var tokens []Token
 	runes := []rune(input)
 	i := 0
@@ -32,8 +43,20 @@ func Lex(input string) ([]Token, error) {
 		case r == '\'':
 			i++
 			tokens = append(tokens, makeToken(TokenApostrophe, runes, start, i))
+		case r == '\\' && i+1 < len(runes) && runes[i+1] == '(':
+			// An escaped marker such as \(up) is kept as literal text. It is
+			// emitted as punctuation so that word markers leave it alone.
+			if value, _ := matchMarker(string(runes[i+1:]), syntax, opts.Lenient); value != "" {
+				i += 1 + len([]rune(value))
+				tok := makeToken(TokenPunct, runes, start, i)
+				tok.Value = value
+				tokens = append(tokens, tok)
+				continue
+			}
+			i++
+			tokens = append(tokens, makeToken(TokenPunct, runes, start, i))
 		case r == '(':
-			token, next, err := tryMarker(input, runes, i)
+			token, next, err := tryMarker(input, runes, i, syntax, opts.Lenient)
 			if err != nil {
 				return nil, err
 			}
@@ -49,6 +72,8 @@ func Lex(input string) ([]Token, error) {
 				switch {
 				case isWordRune(runes[i]):
 					i++
+				case runes[i] == '_' && i < len(runes)-1 && isWordRune(runes[i+1]):
+					i += 2
 				case runes[i] == '\'' && i < len(runes)-1 && unicode.IsLetter(runes[i+1]):
 					i += 2
 					for i < len(runes) && unicode.IsLetter(runes[i]) {
@@ -73,35 +98,85 @@ func Lex(input string) ([]Token, error) {
 	return tokens, nil
 }
 
-func tryMarker(original string, runes []rune, idx int) (*Token, int, error) {
+func tryMarker(original string, runes []rune, idx int, syntax *Syntax, lenient bool) (*Token, int, error) {
 	remaining := string(runes[idx:])
-	for _, marker := range strictSimpleMarkers {
-		if strings.HasPrefix(remaining, marker) {
-			startByte := runeOffsetToByte(original, idx)
-			endByte := startByte + len(marker)
-			return &Token{
-				Kind:  TokenMarker,
-				Value: marker,
-				Start: startByte,
-				End:   endByte,
-			}, idx + len([]rune(marker)), nil
+	value, _ := matchMarker(remaining, syntax, lenient)
+	if value == "" {
+		return nil, idx, nil
+	}
+
+	startByte := runeOffsetToByte(original, idx)
+	endByte := startByte + len(value)
+	return &Token{
+		Kind:  TokenMarker,
+		Value: value,
+		Start: startByte,
+		End:   endByte,
+	}, idx + len([]rune(value)), nil
+}
+
+// matchMarker returns the marker spelled at the start of s together with its
+// canonical spelling, or two empty strings if s does not open with a marker
+// known to syntax. In lenient mode whitespace and letter case may vary, e.g.
+// "( UP ,3 )" is accepted and canonicalised to "(up, 3)".
+func matchMarker(s string, syntax *Syntax, lenient bool) (string, string) {
+	blockPattern, markerPattern := blockMarkerPattern, strictMarkerPattern
+	if lenient {
+		blockPattern, markerPattern = lenientBlockPattern, lenientMarkerPattern
+	}
+
+	if match := blockPattern.FindStringSubmatch(s); match != nil {
+		scope := strings.ToLower(match[1])
+		name := strings.ToLower(match[2])
+		spec, ok := syntax.Lookup(MarkerType(name))
+		// Only word-selecting markers, those taking a count, can cover a
+		// region.
+		if !ok || spec.Args != ArgCount {
+			return "", ""
 		}
+		return match[0], "(" + scope + " " + name + ")"
 	}
 
-	loc := strictCountPattern.FindStringIndex(remaining)
-	if loc != nil {
-		value := remaining[loc[0]:loc[1]]
-		startByte := runeOffsetToByte(original, idx)
-		endByte := startByte + len(value)
-		return &Token{
-			Kind:  TokenMarker,
-			Value: value,
-			Start: startByte,
-			End:   endByte,
-		}, idx + len([]rune(value)), nil
+	match := markerPattern.FindStringSubmatch(s)
+	if match == nil {
+		return "", ""
+	}
+	name := strings.ToLower(match[1])
+	spec, ok := syntax.Lookup(MarkerType(name))
+	if !ok {
+		return "", ""
+	}
+	arg := match[3]
+	if spec.Args == ArgCount {
+		arg = strings.ToLower(arg)
+	}
+	if !acceptsArgument(spec.Args, arg) {
+		return "", ""
+	}
+	// Only word-selecting markers have a direction.
+	if match[2] != "" && spec.Args == ArgString {
+		return "", ""
 	}
 
-	return nil, idx, nil
+	canonical := "(" + name + match[2]
+	if arg != "" {
+		canonical += ", " + arg
+	}
+	return match[0], canonical + ")"
+}
+
+// acceptsArgument reports whether arg (empty when absent) fits the shape.
+func acceptsArgument(shape ArgShape, arg string) bool {
+	switch shape {
+	case ArgNone:
+		return arg == ""
+	case ArgCount:
+		return arg == "" || countPattern.MatchString(arg)
+	case ArgString:
+		return arg != ""
+	default:
+		return false
+	}
 }
 
 func consumePunctuation(runes []rune, idx int) (Token, int) {
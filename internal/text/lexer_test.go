@@ -1,6 +1,9 @@
 package text
 
-import "testing"
+import (
+	"reflect"
+	"testing"
+)
 
 func TestLexBasicSentence(t *testing.T) {
 	input := "Ready, set, go (up) !"
@@ -84,3 +87,140 @@ func TestLexContractionAsSingleWord(t *testing.T) {
 		t.Fatalf("unexpected tokens:\nwant %s\ngot  %s", want, got)
 	}
 }
+
+func TestLexUnderscoreJoinsWord(t *testing.T) {
+	input := "1111_0000 _lead trail_ snake_case"
+	tokens, err := Lex(input)
+	if err != nil {
+		t.Fatalf("Lex returned error: %v", err)
+	}
+
+	got := FormatTokens(tokens)
+	want := `word("1111_0000") space(" ") punct("_") word("lead") space(" ") word("trail") punct("_") space(" ") word("snake_case")`
+	if got != want {
+		t.Fatalf("unexpected tokens:\nwant %s\ngot  %s", want, got)
+	}
+}
+
+func TestLexForwardMarkers(t *testing.T) {
+	input := "(up>) a (cap>, 2) b (up >) c"
+	tokens, err := Lex(input)
+	if err != nil {
+		t.Fatalf("Lex returned error: %v", err)
+	}
+
+	got := FormatTokens(tokens)
+	want := `marker("(up>)") space(" ") word("a") space(" ") marker("(cap>, 2)") space(" ") word("b") space(" ") punct("(") word("up") space(" ") punct(">") punct(")") space(" ") word("c")`
+	if got != want {
+		t.Fatalf("unexpected tokens:\nwant %s\ngot  %s", want, got)
+	}
+}
+
+func TestLexBlockMarkers(t *testing.T) {
+	input := "(begin up) a (end up) (begin nope) (begin  up)"
+	tokens, err := Lex(input)
+	if err != nil {
+		t.Fatalf("Lex returned error: %v", err)
+	}
+
+	got := FormatTokens(tokens)
+	want := `marker("(begin up)") space(" ") word("a") space(" ") marker("(end up)") space(" ") punct("(") word("begin") space(" ") word("nope") punct(")") space(" ") punct("(") word("begin") space("  ") word("up") punct(")")`
+	if got != want {
+		t.Fatalf("unexpected tokens:\nwant %s\ngot  %s", want, got)
+	}
+
+	nodes, err := Parse(tokens)
+	if err != nil {
+		t.Fatalf("Parse returned error: %v", err)
+	}
+	if m := nodes[0].Marker; m == nil || m.Type != MarkerUp || m.Scope != ScopeBlockBegin {
+		t.Fatalf("unexpected begin marker: %#v", m)
+	}
+	if m := nodes[4].Marker; m == nil || m.Type != MarkerUp || m.Scope != ScopeBlockEnd {
+		t.Fatalf("unexpected end marker: %#v", m)
+	}
+}
+
+func TestLexBlockMarkersNeedCountedMarker(t *testing.T) {
+	input := "(begin calc) 2+3 (end calc) (begin swap) (begin dup)"
+	tokens, err := Lex(input)
+	if err != nil {
+		t.Fatalf("Lex returned error: %v", err)
+	}
+
+	var markers []string
+	for _, tok := range tokens {
+		if tok.Kind == TokenMarker {
+			markers = append(markers, tok.Value)
+		}
+	}
+	if want := []string{"(begin dup)"}; !reflect.DeepEqual(markers, want) {
+		t.Fatalf("unexpected markers: want %q, got %q", want, markers)
+	}
+
+	for _, value := range []string{"(begin calc)", "(end swap)", "(begin var)"} {
+		if _, err := Parse([]Token{{Kind: TokenMarker, Value: value}}); err == nil {
+			t.Fatalf("expected Parse to reject %s", value)
+		}
+	}
+}
+
+func TestLexEscapedMarker(t *testing.T) {
+	input := `use \(up, 2) or \(begin up) \(nope) (up)`
+	tokens, err := Lex(input)
+	if err != nil {
+		t.Fatalf("Lex returned error: %v", err)
+	}
+
+	got := FormatTokens(tokens)
+	want := `word("use") space(" ") punct("(up, 2)") space(" ") word("or") space(" ") punct("(begin up)") space(" ") punct("\\") punct("(") word("nope") punct(")") space(" ") marker("(up)")`
+	if got != want {
+		t.Fatalf("unexpected tokens:\nwant %s\ngot  %s", want, got)
+	}
+
+	escaped := tokens[2]
+	if escaped.Start != len("use ") || escaped.End != len(`use \(up, 2)`) {
+		t.Fatalf("unexpected offsets for escaped marker: start=%d end=%d", escaped.Start, escaped.End)
+	}
+}
+
+func TestLexLenientMarkers(t *testing.T) {
+	input := "a (up,3) b ( cap , 2 ) c (UP) d (Title, S) e ( begin Low ) (nope, 2) (up\n)"
+	tokens, err := LexWithOptions(input, Options{Lenient: true})
+	if err != nil {
+		t.Fatalf("Lex returned error: %v", err)
+	}
+
+	var markers []string
+	for _, tok := range tokens {
+		if tok.Kind == TokenMarker {
+			markers = append(markers, tok.Value)
+		}
+	}
+	want := []string{"(up,3)", "( cap , 2 )", "(UP)", "(Title, S)", "( begin Low )"}
+	if len(markers) != len(want) {
+		t.Fatalf("unexpected markers: %q", markers)
+	}
+	for i := range want {
+		if markers[i] != want[i] {
+			t.Fatalf("marker %d: want %q got %q", i, want[i], markers[i])
+		}
+	}
+
+	nodes, err := ParseWithOptions(tokens, Options{Lenient: true})
+	if err != nil {
+		t.Fatalf("Parse returned error: %v", err)
+	}
+	var canonical []string
+	for _, n := range nodes {
+		if n.Kind == NodeMarker {
+			canonical = append(canonical, n.Marker.String())
+		}
+	}
+	wantCanonical := []string{"(up, 3)", "(cap, 2)", "(up)", "(title, s)", "(begin low)"}
+	for i := range wantCanonical {
+		if canonical[i] != wantCanonical[i] {
+			t.Fatalf("canonical %d: want %q got %q", i, wantCanonical[i], canonical[i])
+		}
+	}
+}
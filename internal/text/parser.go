@@ -8,6 +8,13 @@ import (
 
 // Parse converts tokens into semantic nodes, ready for downstream transforms.
 func Parse(tokens []Token) ([]Node, error) {
+	return ParseWithOptions(tokens, Options{})
+}
+
+// ParseWithOptions converts tokens into nodes, validating marker tokens
+// against opts.Syntax.
+func ParseWithOptions(tokens []Token, opts Options) ([]Node, error) {
+	syntax := opts.syntax()
 	nodes := make([]Node, 0, len(tokens))
 
 	for _, tok := range tokens {
@@ -21,7 +28,7 @@ func Parse(tokens []Token) ([]Node, error) {
 		case TokenApostrophe:
 			nodes = append(nodes, Node{Kind: NodeApostrophe, Value: tok.Value})
 		case TokenMarker:
-			marker, err := buildMarker(tok)
+			marker, err := buildMarker(tok, syntax, opts.Lenient)
 			if err != nil {
 				return nil, err
 			}
@@ -34,21 +41,13 @@ func Parse(tokens []Token) ([]Node, error) {
 	return nodes, nil
 }
 
-func buildMarker(tok Token) (*Marker, error) {
+func buildMarker(tok Token, syntax *Syntax, lenient bool) (*Marker, error) {
 	value := tok.Value
-	switch value {
-	case "(hex)":
-		return &Marker{Type: MarkerHex}, nil
-	case "(bin)":
-		return &Marker{Type: MarkerBin}, nil
-	case "(up)":
-		return &Marker{Type: MarkerUp}, nil
-	case "(low)":
-		return &Marker{Type: MarkerLow}, nil
-	case "(cap)":
-		return &Marker{Type: MarkerCap}, nil
+	if lenient {
+		if raw, canonical := matchMarker(value, syntax, true); raw == value {
+			value = canonical
+		}
 	}
-
 	if !strings.HasPrefix(value, "(") || !strings.HasSuffix(value, ")") {
 		return nil, &ParseError{
 			Offset: tok.Start,
@@ -57,50 +56,66 @@ func buildMarker(tok Token) (*Marker, error) {
 	}
 
 	inner := value[1 : len(value)-1]
+	if scope, name, ok := strings.Cut(inner, " "); ok && (scope == "begin" || scope == "end") {
+		spec, ok := syntax.Lookup(MarkerType(name))
+		if !ok || spec.Args != ArgCount {
+			return nil, &ParseError{
+				Offset: tok.Start,
+				Msg:    fmt.Sprintf("invalid marker %q", value),
+			}
+		}
+		return &Marker{Type: spec.Type, Scope: MarkerScope(scope), Offset: tok.Start}, nil
+	}
+
 	parts := strings.SplitN(inner, ", ", 2)
-	if len(parts) != 2 {
+	name, forward := strings.CutSuffix(parts[0], ">")
+	spec, ok := syntax.Lookup(MarkerType(name))
+	if !ok || (forward && spec.Args == ArgString) {
 		return nil, &ParseError{
 			Offset: tok.Start,
 			Msg:    fmt.Sprintf("invalid marker %q", value),
 		}
 	}
 
-	markerName := parts[0]
-	countText := parts[1]
-	if strings.Contains(countText, " ") {
-		return nil, &ParseError{
-			Offset: tok.Start,
-			Msg:    fmt.Sprintf("invalid marker count %q", countText),
+	if len(parts) == 1 {
+		if spec.Args == ArgString {
+			return nil, &ParseError{
+				Offset: tok.Start,
+				Msg:    fmt.Sprintf("marker %q requires an argument", value),
+			}
 		}
+		return &Marker{Type: spec.Type, Forward: forward, Offset: tok.Start}, nil
 	}
 
-	count, err := strconv.Atoi(countText)
-	if err != nil {
+	argText := parts[1]
+	if argText == "" || strings.ContainsAny(argText, " \t\n") {
 		return nil, &ParseError{
 			Offset: tok.Start,
-			Msg:    fmt.Sprintf("invalid marker count %q", countText),
+			Msg:    fmt.Sprintf("invalid marker argument %q", argText),
 		}
 	}
 
-	var markerType MarkerType
-	switch markerName {
-	case "up":
-		markerType = MarkerUp
-	case "low":
-		markerType = MarkerLow
-	case "cap":
-		markerType = MarkerCap
+	switch spec.Args {
+	case ArgCount:
+		if scope := MarkerScope(argText); scope == ScopeSentence || scope == ScopeParagraph {
+			return &Marker{Type: spec.Type, Scope: scope, Forward: forward, Offset: tok.Start}, nil
+		}
+		count, err := strconv.Atoi(argText)
+		if err != nil {
+			return nil, &ParseError{
+				Offset: tok.Start,
+				Msg:    fmt.Sprintf("invalid marker count %q", argText),
+			}
+		}
+		return &Marker{Type: spec.Type, Count: &count, Forward: forward, Offset: tok.Start}, nil
+	case ArgString:
+		return &Marker{Type: spec.Type, Arg: argText, Offset: tok.Start}, nil
 	default:
 		return nil, &ParseError{
 			Offset: tok.Start,
-			Msg:    fmt.Sprintf("invalid marker %q", value),
+			Msg:    fmt.Sprintf("marker %q takes no argument", value),
 		}
 	}
-
-	return &Marker{
-		Type:  markerType,
-		Count: &count,
-	}, nil
 }
 
 // ParseError annotates failures with byte offsets for diagnostics.
@@ -83,6 +83,44 @@ func TestParseCountedMarker(t *testing.T) {
 	}
 }
 
+func TestParseForwardMarker(t *testing.T) {
+	tokens := []Token{
+		{Kind: TokenMarker, Value: "(up>, 3)", Start: 5},
+	}
+
+	nodes, err := Parse(tokens)
+	if err != nil {
+		t.Fatalf("Parse returned error: %v", err)
+	}
+
+	m := nodes[0].Marker
+	if m == nil || m.Type != MarkerUp || !m.Forward {
+		t.Fatalf("unexpected marker: %#v", m)
+	}
+	if m.Count == nil || *m.Count != 3 || m.Offset != 5 {
+		t.Fatalf("unexpected count or offset: %#v", m)
+	}
+}
+
+func TestParseSentenceAndParagraphScopes(t *testing.T) {
+	tokens := []Token{
+		{Kind: TokenMarker, Value: "(cap, s)"},
+		{Kind: TokenMarker, Value: "(low>, p)"},
+	}
+
+	nodes, err := Parse(tokens)
+	if err != nil {
+		t.Fatalf("Parse returned error: %v", err)
+	}
+
+	if m := nodes[0].Marker; m.Type != MarkerCap || m.Scope != ScopeSentence || m.Count != nil {
+		t.Fatalf("unexpected sentence marker: %#v", m)
+	}
+	if m := nodes[1].Marker; m.Type != MarkerLow || m.Scope != ScopeParagraph || !m.Forward {
+		t.Fatalf("unexpected paragraph marker: %#v", m)
+	}
+}
+
 func TestParseInvalidMarkerReturnsError(t *testing.T) {
 	tokens := []Token{
 		{Kind: TokenMarker, Value: "(unknown)", Start: 4},
@@ -0,0 +1,163 @@
+package text
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+)
+
+// ArgShape describes which argument a marker accepts after its name.
+type ArgShape int
+
+// Argument shapes understood by the lexer and parser.
+const (
+	// ArgNone accepts only the bare form, e.g. (hex).
+	ArgNone ArgShape = iota
+	// ArgCount accepts the bare form, an integer count or a sentence/paragraph
+	// scope, e.g. (up), (up, 2), (up, s) and (up, p).
+	ArgCount
+	// ArgString requires a single string argument, e.g. (var, name).
+	ArgString
+)
+
+func (a ArgShape) String() string {
+	switch a {
+	case ArgNone:
+		return "none"
+	case ArgCount:
+		return "count"
+	case ArgString:
+		return "string"
+	default:
+		return fmt.Sprintf("ArgShape(%d)", int(a))
+	}
+}
+
+// MarkerSpec declares how a marker is spelled in the source text.
+type MarkerSpec struct {
+	Type MarkerType
+	Args ArgShape
+}
+
+// Syntax is the set of marker names the lexer and parser recognise. The zero
+// value is empty; use DefaultSyntax for the built-in markers.
+type Syntax struct {
+	specs map[MarkerType]MarkerSpec
+}
+
+var markerNamePattern = regexp.MustCompile(`^[a-z][a-z0-9_]*$`)
+
+var builtinSpecs = []MarkerSpec{
+	{Type: MarkerHex, Args: ArgCount},
+	{Type: MarkerBin, Args: ArgCount},
+	{Type: MarkerOct, Args: ArgCount},
+	{Type: MarkerToHex, Args: ArgCount},
+	{Type: MarkerToBin, Args: ArgCount},
+	{Type: MarkerRoman, Args: ArgCount},
+	{Type: MarkerToRoman, Args: ArgCount},
+	{Type: MarkerWords, Args: ArgCount},
+	{Type: MarkerDigits, Args: ArgCount},
+	{Type: MarkerOrd, Args: ArgCount},
+	{Type: MarkerCalc, Args: ArgNone},
+	{Type: MarkerDelete, Args: ArgCount},
+	{Type: MarkerSwap, Args: ArgNone},
+	{Type: MarkerDup, Args: ArgCount},
+	{Type: MarkerVar, Args: ArgString},
+	{Type: MarkerUp, Args: ArgCount},
+	{Type: MarkerLow, Args: ArgCount},
+	{Type: MarkerCap, Args: ArgCount},
+	{Type: MarkerTitle, Args: ArgCount},
+	{Type: MarkerName, Args: ArgCount},
+	{Type: MarkerSnake, Args: ArgCount},
+	{Type: MarkerCamel, Args: ArgCount},
+	{Type: MarkerPascal, Args: ArgCount},
+	{Type: MarkerKebab, Args: ArgCount},
+	{Type: MarkerConstant, Args: ArgCount},
+}
+
+// defaultSyntax backs Lex and Parse. It is never mutated after init.
+var defaultSyntax = DefaultSyntax()
+
+// DefaultSyntax returns a fresh Syntax populated with the built-in markers.
+func DefaultSyntax() *Syntax {
+	s := &Syntax{}
+	for _, spec := range builtinSpecs {
+		if err := s.Add(spec); err != nil {
+			panic(err)
+		}
+	}
+	return s
+}
+
+// Add registers a marker spelling. Names must be lower-case identifiers and
+// may only be registered once.
+func (s *Syntax) Add(spec MarkerSpec) error {
+	if !markerNamePattern.MatchString(string(spec.Type)) {
+		return fmt.Errorf("invalid marker name %q", spec.Type)
+	}
+	if spec.Args < ArgNone || spec.Args > ArgString {
+		return fmt.Errorf("marker %q: invalid argument shape %d", spec.Type, spec.Args)
+	}
+	if s.specs == nil {
+		s.specs = make(map[MarkerType]MarkerSpec)
+	}
+	if _, exists := s.specs[spec.Type]; exists {
+		return fmt.Errorf("marker %q already registered", spec.Type)
+	}
+	s.specs[spec.Type] = spec
+	return nil
+}
+
+// Lookup returns the spec registered for name.
+func (s *Syntax) Lookup(name MarkerType) (MarkerSpec, bool) {
+	if s == nil {
+		return MarkerSpec{}, false
+	}
+	spec, ok := s.specs[name]
+	return spec, ok
+}
+
+// Specs lists the registered markers sorted by name.
+func (s *Syntax) Specs() []MarkerSpec {
+	if s == nil {
+		return nil
+	}
+	out := make([]MarkerSpec, 0, len(s.specs))
+	for _, spec := range s.specs {
+		out = append(out, spec)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Type < out[j].Type })
+	return out
+}
+
+// Clone returns an independent copy that can be extended without affecting s.
+func (s *Syntax) Clone() *Syntax {
+	c := &Syntax{specs: make(map[MarkerType]MarkerSpec, len(s.specsOrEmpty()))}
+	for name, spec := range s.specsOrEmpty() {
+		c.specs[name] = spec
+	}
+	return c
+}
+
+func (s *Syntax) specsOrEmpty() map[MarkerType]MarkerSpec {
+	if s == nil {
+		return nil
+	}
+	return s.specs
+}
+
+// Options configures LexWithOptions and ParseWithOptions.
+type Options struct {
+	// Syntax lists the recognised markers. Nil means DefaultSyntax.
+	Syntax *Syntax
+	// Lenient accepts markers whose whitespace or letter case differs from
+	// the canonical spelling, such as "(UP)" or "( cap ,2 )".
+	Lenient bool
+}
+
+func (o Options) syntax() *Syntax {
+	if o.Syntax == nil {
+		return defaultSyntax
+	}
+	return o.Syntax
+}
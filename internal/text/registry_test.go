@@ -0,0 +1,84 @@
+package text
+
+import "testing"
+
+func TestSyntaxAddRejectsDuplicatesAndBadNames(t *testing.T) {
+	t.Parallel()
+
+	s := DefaultSyntax()
+	if err := s.Add(MarkerSpec{Type: MarkerUp, Args: ArgCount}); err == nil {
+		t.Fatal("expected error for duplicate marker")
+	}
+	if err := s.Add(MarkerSpec{Type: "Bad Name"}); err == nil {
+		t.Fatal("expected error for invalid marker name")
+	}
+	if err := s.Add(MarkerSpec{Type: "rev", Args: ArgCount}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, ok := DefaultSyntax().Lookup("rev"); ok {
+		t.Fatal("DefaultSyntax must return independent copies")
+	}
+}
+
+func TestLexAndParseCustomMarkers(t *testing.T) {
+	t.Parallel()
+
+	s := DefaultSyntax()
+	for _, spec := range []MarkerSpec{
+		{Type: "rev", Args: ArgNone},
+		{Type: "shout", Args: ArgCount},
+		{Type: "tag", Args: ArgString},
+	} {
+		if err := s.Add(spec); err != nil {
+			t.Fatalf("Add(%s): %v", spec.Type, err)
+		}
+	}
+	opts := Options{Syntax: s}
+
+	input := "a (rev) b (shout, 2) c (tag, intro) d (rev, 2) (tag)"
+	tokens, err := LexWithOptions(input, opts)
+	if err != nil {
+		t.Fatalf("Lex returned error: %v", err)
+	}
+
+	got := FormatTokens(tokens)
+	want := `word("a") space(" ") marker("(rev)") space(" ") word("b") space(" ") marker("(shout, 2)") space(" ") word("c") space(" ") marker("(tag, intro)") space(" ") word("d") space(" ") punct("(") word("rev") punct(",") space(" ") word("2") punct(")") space(" ") punct("(") word("tag") punct(")")`
+	if got != want {
+		t.Fatalf("unexpected tokens:\nwant %s\ngot  %s", want, got)
+	}
+
+	nodes, err := ParseWithOptions(tokens, opts)
+	if err != nil {
+		t.Fatalf("Parse returned error: %v", err)
+	}
+	if m := nodes[6].Marker; m == nil || m.Type != "shout" || m.Count == nil || *m.Count != 2 {
+		t.Fatalf("unexpected shout marker: %#v", m)
+	}
+	if m := nodes[10].Marker; m == nil || m.Type != "tag" || m.Arg != "intro" {
+		t.Fatalf("unexpected tag marker: %#v", m)
+	}
+
+	// The default syntax is unaffected.
+	defaultTokens, err := Lex(input)
+	if err != nil {
+		t.Fatalf("Lex returned error: %v", err)
+	}
+	for _, tok := range defaultTokens {
+		if tok.Kind == TokenMarker {
+			t.Fatalf("unexpected marker with default syntax: %q", tok.Value)
+		}
+	}
+}
+
+func TestParseRejectsMissingStringArgument(t *testing.T) {
+	t.Parallel()
+
+	s := DefaultSyntax()
+	if err := s.Add(MarkerSpec{Type: "tag", Args: ArgString}); err != nil {
+		t.Fatalf("Add: %v", err)
+	}
+	tokens := []Token{{Kind: TokenMarker, Value: "(tag)", Start: 3}}
+	if _, err := ParseWithOptions(tokens, Options{Syntax: s}); err == nil {
+		t.Fatal("expected error for missing argument, got nil")
+	}
+}
@@ -0,0 +1,30 @@
+package text
+
+import (
+	"fmt"
+
+	"go-reloaded/internal/diag"
+)
+
+// SpellingDiagnostics notes every marker node whose source spelling differs
+// from its canonical form. Only lenient lexing produces such markers.
+func SpellingDiagnostics(nodes []Node) []diag.Diagnostic {
+	var out []diag.Diagnostic
+	for _, node := range nodes {
+		if node.Kind != NodeMarker || node.Marker == nil {
+			continue
+		}
+		canonical := node.Marker.String()
+		if node.Value == canonical {
+			continue
+		}
+		out = append(out, diag.Diagnostic{
+			Offset:   node.Marker.Offset,
+			Marker:   node.Value,
+			Code:     diag.CodeNonCanonical,
+			Severity: diag.SeverityNote,
+			Msg:      fmt.Sprintf("non-canonical spelling, write %s", canonical),
+		})
+	}
+	return out
+}
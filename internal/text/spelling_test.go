@@ -0,0 +1,60 @@
+package text
+
+import (
+	"testing"
+
+	"go-reloaded/internal/diag"
+)
+
+func TestMarkerString(t *testing.T) {
+	t.Parallel()
+
+	three := 3
+	neg := -1
+	cases := []struct {
+		marker Marker
+		want   string
+	}{
+		{Marker{Type: MarkerUp}, "(up)"},
+		{Marker{Type: MarkerCap, Count: &three}, "(cap, 3)"},
+		{Marker{Type: MarkerUp, Count: &neg}, "(up, -1)"},
+		{Marker{Type: MarkerLow, Forward: true, Count: &three}, "(low>, 3)"},
+		{Marker{Type: MarkerCap, Scope: ScopeSentence}, "(cap, s)"},
+		{Marker{Type: MarkerUp, Scope: ScopeBlockEnd}, "(end up)"},
+		{Marker{Type: "var", Arg: "product"}, "(var, product)"},
+	}
+	for _, tc := range cases {
+		if got := tc.marker.String(); got != tc.want {
+			t.Fatalf("String() = %q, want %q", got, tc.want)
+		}
+	}
+}
+
+func TestSpellingDiagnostics(t *testing.T) {
+	t.Parallel()
+
+	input := "one (up) two ( up,2 ) three (Cap)"
+	opts := Options{Lenient: true}
+	tokens, err := LexWithOptions(input, opts)
+	if err != nil {
+		t.Fatalf("Lex returned error: %v", err)
+	}
+	nodes, err := ParseWithOptions(tokens, opts)
+	if err != nil {
+		t.Fatalf("Parse returned error: %v", err)
+	}
+
+	got := SpellingDiagnostics(nodes)
+	want := []diag.Diagnostic{
+		{Offset: 13, Marker: "( up,2 )", Code: diag.CodeNonCanonical, Severity: diag.SeverityNote, Msg: "non-canonical spelling, write (up, 2)"},
+		{Offset: 28, Marker: "(Cap)", Code: diag.CodeNonCanonical, Severity: diag.SeverityNote, Msg: "non-canonical spelling, write (cap)"},
+	}
+	if len(got) != len(want) {
+		t.Fatalf("expected %d diagnostics, got %v", len(want), got)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Fatalf("diagnostic %d:\nwant %+v\ngot  %+v", i, want[i], got[i])
+		}
+	}
+}
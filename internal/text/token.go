@@ -1,5 +1,10 @@
 package text
 
+import (
+	"strconv"
+	"strings"
+)
+
 // TokenKind identifies the lexical category assigned by the lexer.
 type TokenKind string
 
@@ -20,16 +25,55 @@ type Token struct {
 	End   int // exclusive byte offset
 }
 
-// MarkerType enumerates supported transformation markers.
+// MarkerType names a transformation marker. Built-in names are listed below;
+// further names can be registered through a Syntax.
 type MarkerType string
 
-// Marker type identifiers supported by the engine.
+// Built-in marker type identifiers.
 const (
-	MarkerHex MarkerType = "hex"
-	MarkerBin MarkerType = "bin"
-	MarkerUp  MarkerType = "up"
-	MarkerLow MarkerType = "low"
-	MarkerCap MarkerType = "cap"
+	MarkerHex      MarkerType = "hex"
+	MarkerBin      MarkerType = "bin"
+	MarkerOct      MarkerType = "oct"
+	MarkerToHex    MarkerType = "tohex"
+	MarkerToBin    MarkerType = "tobin"
+	MarkerRoman    MarkerType = "roman"
+	MarkerToRoman  MarkerType = "toroman"
+	MarkerWords    MarkerType = "words"
+	MarkerDigits   MarkerType = "digits"
+	MarkerOrd      MarkerType = "ord"
+	MarkerCalc     MarkerType = "calc"
+	MarkerDelete   MarkerType = "del"
+	MarkerSwap     MarkerType = "swap"
+	MarkerDup      MarkerType = "dup"
+	MarkerVar      MarkerType = "var"
+	MarkerUp       MarkerType = "up"
+	MarkerLow      MarkerType = "low"
+	MarkerCap      MarkerType = "cap"
+	MarkerTitle    MarkerType = "title"
+	MarkerName     MarkerType = "name"
+	MarkerSnake    MarkerType = "snake"
+	MarkerCamel    MarkerType = "camel"
+	MarkerPascal   MarkerType = "pascal"
+	MarkerKebab    MarkerType = "kebab"
+	MarkerConstant MarkerType = "constant"
+)
+
+// MarkerScope selects which words a marker acts on.
+type MarkerScope string
+
+// Marker scopes recognised by the parser.
+const (
+	// ScopeWords acts on a count of words next to the marker (default).
+	ScopeWords MarkerScope = ""
+	// ScopeBlockBegin opens a region, written (begin up), that extends to the
+	// matching (end up).
+	ScopeBlockBegin MarkerScope = "begin"
+	// ScopeBlockEnd closes a region opened by ScopeBlockBegin.
+	ScopeBlockEnd MarkerScope = "end"
+	// ScopeSentence acts on a whole sentence, written (cap, s).
+	ScopeSentence MarkerScope = "s"
+	// ScopeParagraph acts on a whole paragraph, written (low, p).
+	ScopeParagraph MarkerScope = "p"
 )
 
 // NodeKind identifies the semantic category produced by the parser.
@@ -44,16 +88,59 @@ const (
 	NodeMarker     NodeKind = "marker"
 )
 
+// Transform records a marker that rewrote a word node.
+type Transform struct {
+	Type   MarkerType
+	Offset int // byte offset of the marker in the source text
+}
+
 // Node is a parsed element from the token stream.
 type Node struct {
-	Kind           NodeKind
-	Value          string
-	Marker         *Marker
-	CaseTransform  *MarkerType // tracks last case transformation applied (up/low/cap) for word nodes
+	Kind   NodeKind
+	Value  string
+	Marker *Marker
+	// Transforms lists the markers applied to a word node, oldest first.
+	Transforms []Transform
+}
+
+// LastTransform returns the most recent marker applied to the node.
+func (n Node) LastTransform() (Transform, bool) {
+	if len(n.Transforms) == 0 {
+		return Transform{}, false
+	}
+	return n.Transforms[len(n.Transforms)-1], true
 }
 
 // Marker captures a transformation directive such as (up, 2).
 type Marker struct {
-	Type  MarkerType
-	Count *int
+	Type    MarkerType
+	Count   *int   // set for ArgCount markers written with a numeric count
+	Arg     string // set for ArgString markers
+	Forward bool   // acts on the following words, written (up>) or (up>, 2)
+	Scope   MarkerScope
+	Offset  int // byte offset of the marker in the original input
+}
+
+// String renders the marker in its canonical spelling, e.g. "(up, 2)".
+func (m Marker) String() string {
+	if m.Scope == ScopeBlockBegin || m.Scope == ScopeBlockEnd {
+		return "(" + string(m.Scope) + " " + string(m.Type) + ")"
+	}
+
+	var b strings.Builder
+	b.WriteString("(")
+	b.WriteString(string(m.Type))
+	if m.Forward {
+		b.WriteString(">")
+	}
+	switch {
+	case m.Scope == ScopeSentence || m.Scope == ScopeParagraph:
+		b.WriteString(", " + string(m.Scope))
+	case m.Count != nil:
+		b.WriteString(", " + strconv.Itoa(*m.Count))
+	case m.Arg != "":
+		b.WriteString(", " + m.Arg)
+	}
+	b.WriteString(")")
+	return b.String()
 }